@@ -150,6 +150,25 @@ func BenchmarkTest(b *testing.B) {
 	}
 }
 
+// BenchmarkTestArchetype-16    	500	   2346157 ns/op	  802818 B/op	       1 allocs/op
+func BenchmarkTestArchetype(b *testing.B) {
+	em := ecs.NewArchetypeEntityManager(100000)
+	em.Add(generateEntities(100000)...)
+
+	sm := ecs.NewSystemManager()
+	sm.Add(&mockupMovementSystem{})
+
+	engine := ecs.NewDefaultEngine(em, sm)
+	engine.Setup()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for b.Loop() {
+		engine.Tick()
+	}
+}
+
 type arkMoveSystem struct {
 	filter *arkecs.Filter2[position, velocity]
 }
@@ -0,0 +1,267 @@
+package ecs
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"iter"
+	"math/bits"
+
+	"github.com/bolom009/ecs/intmap"
+)
+
+// Bitset is a variable-width mask to identify more than 64 Components.
+// The first 64 bits are stored inline, so the masks of up to 64 Components never allocate.
+// A Bitset is a value: all the methods return a new Bitset instead of modifying it.
+type Bitset struct {
+	lo uint64
+	// hi contains the bits beyond 63 without trailing zero words.
+	hi []uint64
+}
+
+// NewBitset creates a new Bitset with the given bits set.
+func NewBitset(bits ...uint) Bitset {
+	b := Bitset{}
+	for _, bit := range bits {
+		b = b.Set(bit)
+	}
+	return b
+}
+
+// BitsetFromMask creates a new Bitset from the 64 bits of a Component mask.
+func BitsetFromMask(mask uint64) Bitset {
+	return Bitset{lo: mask}
+}
+
+// Mask returns the first 64 bits.
+func (b Bitset) Mask() uint64 {
+	return b.lo
+}
+
+// IsWide reports whether a bit beyond 63 is set.
+func (b Bitset) IsWide() bool {
+	return len(b.hi) > 0
+}
+
+// IsZero reports whether no bit is set.
+func (b Bitset) IsZero() bool {
+	return b.lo == 0 && len(b.hi) == 0
+}
+
+// Has reports whether the bit is set.
+func (b Bitset) Has(bit uint) bool {
+	if bit < 64 {
+		return b.lo&(1<<bit) != 0
+	}
+
+	word := bit/64 - 1
+	return word < uint(len(b.hi)) && b.hi[word]&(1<<(bit%64)) != 0
+}
+
+// Set returns a copy with the bit set.
+func (b Bitset) Set(bit uint) Bitset {
+	if bit < 64 {
+		b.lo |= 1 << bit
+		return b
+	}
+
+	word := bit/64 - 1
+	hi := make([]uint64, max(uint(len(b.hi)), word+1))
+	copy(hi, b.hi)
+	hi[word] |= 1 << (bit % 64)
+	return Bitset{lo: b.lo, hi: hi}
+}
+
+// Unset returns a copy with the bit cleared.
+func (b Bitset) Unset(bit uint) Bitset {
+	if bit < 64 {
+		b.lo &^= 1 << bit
+		return b
+	}
+
+	if !b.Has(bit) {
+		return b
+	}
+
+	hi := make([]uint64, len(b.hi))
+	copy(hi, b.hi)
+	hi[bit/64-1] &^= 1 << (bit % 64)
+	return Bitset{lo: b.lo, hi: trim(hi)}
+}
+
+// Or returns the union of both Bitsets.
+func (b Bitset) Or(other Bitset) Bitset {
+	if len(other.hi) == 0 {
+		b.lo |= other.lo
+		return b
+	}
+
+	hi := make([]uint64, max(len(b.hi), len(other.hi)))
+	copy(hi, b.hi)
+	for i, word := range other.hi {
+		hi[i] |= word
+	}
+	return Bitset{lo: b.lo | other.lo, hi: hi}
+}
+
+// AndNot returns the bits, which are set in b but not in the other Bitset.
+func (b Bitset) AndNot(other Bitset) Bitset {
+	if len(b.hi) == 0 {
+		b.lo &^= other.lo
+		return b
+	}
+
+	hi := make([]uint64, len(b.hi))
+	copy(hi, b.hi)
+	for i := 0; i < len(hi) && i < len(other.hi); i++ {
+		hi[i] &^= other.hi[i]
+	}
+	return Bitset{lo: b.lo &^ other.lo, hi: trim(hi)}
+}
+
+// Contains reports whether all the bits of the other Bitset are set.
+func (b Bitset) Contains(other Bitset) bool {
+	if b.lo&other.lo != other.lo || len(other.hi) > len(b.hi) {
+		return false
+	}
+
+	for i, word := range other.hi {
+		if b.hi[i]&word != word {
+			return false
+		}
+	}
+	return true
+}
+
+// Intersects reports whether at least one bit is set in both Bitsets.
+func (b Bitset) Intersects(other Bitset) bool {
+	if b.lo&other.lo != 0 {
+		return true
+	}
+
+	for i := 0; i < len(b.hi) && i < len(other.hi); i++ {
+		if b.hi[i]&other.hi[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Equal reports whether both Bitsets have the same bits set.
+func (b Bitset) Equal(other Bitset) bool {
+	if b.lo != other.lo || len(b.hi) != len(other.hi) {
+		return false
+	}
+
+	for i, word := range b.hi {
+		if other.hi[i] != word {
+			return false
+		}
+	}
+	return true
+}
+
+// Count returns the number of bits set.
+func (b Bitset) Count() int {
+	count := bits.OnesCount64(b.lo)
+	for _, word := range b.hi {
+		count += bits.OnesCount64(word)
+	}
+	return count
+}
+
+// All returns an iterator over the positions of the bits set in ascending order.
+func (b Bitset) All() iter.Seq[uint] {
+	return func(yield func(uint) bool) {
+		for i, word := range append([]uint64{b.lo}, b.hi...) {
+			for word != 0 {
+				bit := uint(bits.TrailingZeros64(word))
+				if !yield(uint(i)*64 + bit) {
+					return
+				}
+				word &= word - 1
+			}
+		}
+	}
+}
+
+// MarshalJSON encodes a Bitset up to 64 bits as a number and a wider one as a list of words.
+func (b Bitset) MarshalJSON() ([]byte, error) {
+	if len(b.hi) == 0 {
+		return json.Marshal(b.lo)
+	}
+
+	return json.Marshal(append([]uint64{b.lo}, b.hi...))
+}
+
+// UnmarshalJSON decodes a Bitset encoded by MarshalJSON.
+func (b *Bitset) UnmarshalJSON(data []byte) error {
+	var words []uint64
+	if len(data) > 0 && data[0] != '[' {
+		var lo uint64
+		if err := json.Unmarshal(data, &lo); err != nil {
+			return err
+		}
+		words = []uint64{lo}
+	} else if err := json.Unmarshal(data, &words); err != nil {
+		return err
+	}
+
+	*b = Bitset{}
+	if len(words) > 0 {
+		b.lo = words[0]
+		b.hi = trim(append([]uint64(nil), words[1:]...))
+	}
+	return nil
+}
+
+// key returns all the words as a string to be used as a map key.
+func (b Bitset) key() string {
+	buf := make([]byte, 0, 8*(len(b.hi)+1))
+	buf = binary.LittleEndian.AppendUint64(buf, b.lo)
+	for _, word := range b.hi {
+		buf = binary.LittleEndian.AppendUint64(buf, word)
+	}
+	return string(buf)
+}
+
+// trim removes the trailing zero words.
+func trim(words []uint64) []uint64 {
+	for len(words) > 0 && words[len(words)-1] == 0 {
+		words = words[:len(words)-1]
+	}
+	if len(words) == 0 {
+		return nil
+	}
+	return words
+}
+
+// bitsetIndex maps Bitsets to values and keeps a fast path for Bitsets up to 64 bits.
+type bitsetIndex[V any] struct {
+	narrow *intmap.Map[uint64, V]
+	wide   map[string]V
+}
+
+func newBitsetIndex[V any](cap int) *bitsetIndex[V] {
+	return &bitsetIndex[V]{
+		narrow: intmap.New[uint64, V](cap),
+		wide:   make(map[string]V),
+	}
+}
+
+func (i *bitsetIndex[V]) get(b Bitset) (V, bool) {
+	if len(b.hi) == 0 {
+		return i.narrow.Get(b.lo)
+	}
+
+	v, ok := i.wide[b.key()]
+	return v, ok
+}
+
+func (i *bitsetIndex[V]) put(b Bitset, v V) {
+	if len(b.hi) == 0 {
+		i.narrow.Put(b.lo, v)
+		return
+	}
+
+	i.wide[b.key()] = v
+}
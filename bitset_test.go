@@ -0,0 +1,115 @@
+package ecs_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bolom009/ecs"
+)
+
+func TestBitset_Set_Should_Work_Beyond_64_Bits(t *testing.T) {
+	b := ecs.NewBitset(1, 64, 200)
+	for _, bit := range []uint{1, 64, 200} {
+		if !b.Has(bit) {
+			t.Errorf("Bit %d should be set", bit)
+		}
+	}
+	if b.Has(65) {
+		t.Error("Bit 65 should not be set")
+	}
+	if !b.IsWide() {
+		t.Error("Bitset should be wide")
+	}
+	if b.Count() != 3 {
+		t.Errorf("Bitset should have 3 bits set, but got %d", b.Count())
+	}
+	if b.Mask() != 2 {
+		t.Errorf("Mask should be 2, but got %d", b.Mask())
+	}
+}
+
+func TestBitset_Unset_Should_Not_Modify_The_Original(t *testing.T) {
+	b := ecs.NewBitset(3, 130)
+	c := b.Unset(130)
+	if !b.Has(130) {
+		t.Error("Original Bitset should keep bit 130")
+	}
+	if c.IsWide() {
+		t.Error("Bitset without bits beyond 63 should not be wide")
+	}
+	if !c.Equal(ecs.BitsetFromMask(8)) {
+		t.Error("Bitset should equal the mask 8")
+	}
+}
+
+func TestBitset_Contains_And_Intersects(t *testing.T) {
+	entity := ecs.NewBitset(0, 1, 70)
+	if !entity.Contains(ecs.NewBitset(0, 70)) {
+		t.Error("Bitset should contain bits 0 and 70")
+	}
+	if entity.Contains(ecs.NewBitset(0, 71)) {
+		t.Error("Bitset should not contain bit 71")
+	}
+	if entity.Contains(ecs.NewBitset(0, 128)) {
+		t.Error("Bitset should not contain bit 128")
+	}
+	if !entity.Intersects(ecs.NewBitset(5, 70)) {
+		t.Error("Bitset should intersect on bit 70")
+	}
+	if entity.Intersects(ecs.NewBitset(5, 71)) {
+		t.Error("Bitset should not intersect")
+	}
+}
+
+func TestBitset_Or_And_AndNot(t *testing.T) {
+	b := ecs.NewBitset(1).Or(ecs.NewBitset(100))
+	if !b.Equal(ecs.NewBitset(1, 100)) {
+		t.Error("Or should combine both Bitsets")
+	}
+	b = b.AndNot(ecs.NewBitset(100))
+	if !b.Equal(ecs.NewBitset(1)) {
+		t.Error("AndNot should clear bit 100")
+	}
+}
+
+func TestBitset_JSON_Should_Keep_A_Number_Up_To_64_Bits(t *testing.T) {
+	data, err := json.Marshal(ecs.BitsetFromMask(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "5" {
+		t.Errorf("Bitset should be encoded as 5, but got %s", data)
+	}
+
+	var narrow, wide ecs.Bitset
+	if err := json.Unmarshal(data, &narrow); err != nil {
+		t.Fatal(err)
+	}
+	if !narrow.Equal(ecs.BitsetFromMask(5)) {
+		t.Error("Bitset should be decoded as mask 5")
+	}
+
+	data, _ = json.Marshal(ecs.NewBitset(0, 65))
+	if err := json.Unmarshal(data, &wide); err != nil {
+		t.Fatal(err)
+	}
+	if !wide.Equal(ecs.NewBitset(0, 65)) {
+		t.Errorf("Bitset should be decoded from %s", data)
+	}
+}
+
+func TestBitset_All_Should_Yield_Bits_In_Ascending_Order(t *testing.T) {
+	var got []uint
+	for bit := range ecs.NewBitset(130, 3, 64, 0).All() {
+		got = append(got, bit)
+	}
+	want := []uint{0, 3, 64, 130}
+	if len(got) != len(want) {
+		t.Fatalf("Bits should be %v, but got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Bits should be %v, but got %v", want, got)
+		}
+	}
+}
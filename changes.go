@@ -0,0 +1,214 @@
+package ecs
+
+import (
+	"cmp"
+	"math/bits"
+	"slices"
+)
+
+// runBits is the number of the low bits of a stamp, which count the runs of the systems within a world tick.
+// So a System finds the Components changed by the systems processed after it in the previous tick.
+const runBits = 24
+
+// componentTicks contains the stamps, at which the Component of the bit was added and changed last.
+// A stamp is the world tick in the high bits and the run of a System in the low bits.
+type componentTicks struct {
+	bit     uint
+	added   uint64
+	changed uint64
+}
+
+// worldClock counts the world ticks of an EntityManager and the runs of the systems within a tick.
+type worldClock struct {
+	tick uint64
+	run  uint64
+	// last is the stamp of the previous run of the running System.
+	last    uint64
+	running bool
+}
+
+// newWorldClock creates a worldClock starting at the first world tick.
+func newWorldClock() *worldClock {
+	return &worldClock{tick: 1}
+}
+
+// advance starts the next world tick.
+func (c *worldClock) advance() {
+	c.tick++
+	c.run = 0
+}
+
+// stamp returns the stamp, which marks the Components added or changed now.
+func (c *worldClock) stamp() uint64 {
+	return stampOf(c.tick, c.run)
+}
+
+// begin starts the run of a System, which ran last at the given stamp, and returns the stamp of this run.
+func (c *worldClock) begin(last uint64) uint64 {
+	c.run++
+	c.last = last
+	c.running = true
+	return c.stamp()
+}
+
+// end finishes the run of a System. Changes afterwards, e.g. by the flushed Commands,
+// get a later stamp, so the System finds them in its next run.
+func (c *worldClock) end() {
+	c.run++
+	c.running = false
+}
+
+// since returns the first stamp matched by Added and Changed without Filter.Since:
+// the one after the previous run of the running System or the start of the current world tick.
+func (c *worldClock) since() uint64 {
+	if c.running {
+		return c.last + 1
+	}
+
+	return stampOf(c.tick, 0)
+}
+
+// stampOf returns the stamp of the run within the world tick.
+func stampOf(tick, run uint64) uint64 {
+	return tick<<runBits | min(run, 1<<runBits-1)
+}
+
+// MarkChanged marks the Component of the mask as changed in the current tick of the EntityManager.
+// Components are marked automatically when they are added or replaced by Set,
+// modifications through a pointer must be marked by hand.
+func (e *Entity) MarkChanged(mask uint64) {
+	if e.Masked.lo&mask == mask {
+		e.stampMask(mask, e.tick(), false)
+	}
+}
+
+// MarkChangedBit marks the Component of the bit as changed in the current tick of the EntityManager.
+func (e *Entity) MarkChangedBit(bit uint) {
+	if e.Masked.Has(bit) {
+		e.stampBit(bit, e.tick(), false)
+	}
+}
+
+// AddedSince reports whether all the Components of the mask were added at or after the tick.
+func (e *Entity) AddedSince(mask uint64, tick uint64) bool {
+	return e.since(Bitset{lo: mask}, stampOf(tick, 0), true)
+}
+
+// ChangedSince reports whether all the Components of the mask were changed at or after the tick.
+// Adding a Component counts as a change.
+func (e *Entity) ChangedSince(mask uint64, tick uint64) bool {
+	return e.since(Bitset{lo: mask}, stampOf(tick, 0), false)
+}
+
+// tick returns the current stamp of the EntityManager or 0, if the entity is not managed.
+func (e *Entity) tick() uint64 {
+	if e.observer == nil {
+		return 0
+	}
+
+	return e.observer.currentTick()
+}
+
+// changedSince returns the first stamp matched by Added and Changed without Filter.Since
+// or 0, if the entity is not managed.
+func (e *Entity) changedSince() uint64 {
+	if e.observer == nil {
+		return 0
+	}
+
+	return e.observer.changedSince()
+}
+
+// stampAll sets the ticks of all the components, when the entity is added to an EntityManager.
+func (e *Entity) stampAll(tick uint64) {
+	e.ticks = e.ticks[:0]
+	e.stampMask(e.Masked.lo, tick, true)
+	for i, word := range e.Masked.hi {
+		for word != 0 {
+			e.stampBit(uint(i+1)*64+uint(bits.TrailingZeros64(word)), tick, true)
+			word &= word - 1
+		}
+	}
+}
+
+// stampMask sets the ticks of each bit of the mask.
+func (e *Entity) stampMask(mask uint64, tick uint64, added bool) {
+	for mask != 0 {
+		e.stampBit(uint(bits.TrailingZeros64(mask)), tick, added)
+		mask &= mask - 1
+	}
+}
+
+// stampBit sets the ticks of the bit and inserts them if needed.
+// The entities without an EntityManager are stamped, when they are added to one.
+func (e *Entity) stampBit(bit uint, tick uint64, added bool) {
+	if tick == 0 {
+		return
+	}
+
+	i, ok := e.ticksOf(bit)
+	if !ok {
+		e.ticks = slices.Insert(e.ticks, i, componentTicks{bit: bit})
+	}
+	e.ticks[i].changed = tick
+	if added {
+		e.ticks[i].added = tick
+	}
+}
+
+// unstampMask drops the ticks of each bit of the mask, when its Component is removed.
+func (e *Entity) unstampMask(mask uint64) {
+	for mask != 0 {
+		e.unstampBit(uint(bits.TrailingZeros64(mask)))
+		mask &= mask - 1
+	}
+}
+
+// unstampBit drops the ticks of the bit, when its Component is removed.
+func (e *Entity) unstampBit(bit uint) {
+	if i, ok := e.ticksOf(bit); ok {
+		e.ticks = slices.Delete(e.ticks, i, i+1)
+	}
+}
+
+// ticksOf returns the index of the ticks of the bit, which are sorted by their bit,
+// and whether they were found.
+func (e *Entity) ticksOf(bit uint) (int, bool) {
+	return slices.BinarySearchFunc(e.ticks, bit, func(t componentTicks, bit uint) int {
+		return cmp.Compare(t.bit, bit)
+	})
+}
+
+// since reports whether all the bits of the mask were added or changed at or after the stamp.
+func (e *Entity) since(mask Bitset, tick uint64, added bool) bool {
+	if !e.Masked.Contains(mask) {
+		return false
+	}
+
+	for i, word := range mask.hi {
+		if !e.wordSince(word, uint(i+1)*64, tick, added) {
+			return false
+		}
+	}
+	return e.wordSince(mask.lo, 0, tick, added)
+}
+
+// wordSince checks the bits of a single word of a Bitset starting at the offset.
+func (e *Entity) wordSince(word uint64, offset uint, tick uint64, added bool) bool {
+	for word != 0 {
+		i, ok := e.ticksOf(offset + uint(bits.TrailingZeros64(word)))
+		if !ok {
+			return false
+		}
+
+		t := e.ticks[i].changed
+		if added {
+			t = e.ticks[i].added
+		}
+		if t < tick {
+			return false
+		}
+		word &= word - 1
+	}
+	return true
+}
@@ -0,0 +1,188 @@
+package ecs_test
+
+import (
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/bolom009/ecs"
+)
+
+func TestEntity_ChangedSince_Should_Follow_World_Tick(t *testing.T) {
+	em := ecs.NewEntityManager()
+	e := em.NewEntity([]ecs.Component{&mockComponent{name: "position", mask: 1}})
+	start := em.Tick()
+	if !e.AddedSince(1, start) || !e.ChangedSince(1, start) {
+		t.Error("Component should be added and changed in the tick of adding the entity")
+	}
+	em.AdvanceTick()
+	if e.ChangedSince(1, em.Tick()) {
+		t.Error("Component should not be changed in the next tick")
+	}
+	e.MarkChanged(1)
+	if !e.ChangedSince(1, em.Tick()) || e.AddedSince(1, em.Tick()) {
+		t.Error("Component should be changed but not added in the next tick")
+	}
+	if e.ChangedSince(2, start) {
+		t.Error("Missing Component should not be changed")
+	}
+}
+
+func TestEntity_AddedSince_Should_Follow_Wide_Component(t *testing.T) {
+	em := ecs.NewEntityManager()
+	e := em.NewEntity([]ecs.Component{&mockWideComponent{bit: 500}})
+	wide := ecs.NewFilter().AddedBits(ecs.NewBitset(500))
+	if len(em.FilterBy(wide)) != 1 {
+		t.Error("Wide Component should be added in the tick of adding the entity")
+	}
+	em.AdvanceTick()
+	e.RemoveBit(500)
+	if len(em.FilterBy(wide)) != 0 {
+		t.Error("Removed wide Component should not be added")
+	}
+	e.Add(&mockWideComponent{bit: 500})
+	if len(em.FilterBy(wide)) != 1 || e.AddedSince(1, em.Tick()) {
+		t.Error("Wide Component should be added again in the next tick")
+	}
+}
+
+func TestEntityManager_Add_Should_Not_Allocate_Ticks_For_Missing_Components(t *testing.T) {
+	const n = 1000
+	em := ecs.NewEntityManager(n)
+	entities := make([]*ecs.Entity, n)
+	for i := range entities {
+		entities[i] = ecs.NewEntity([]ecs.Component{&mockWideComponent{bit: 500}})
+	}
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	em.Add(entities...)
+	runtime.ReadMemStats(&after)
+	if perEntity := (after.TotalAlloc - before.TotalAlloc) / n; perEntity > 256 {
+		t.Errorf("Ticks should be stored for the present Components only, but got %d bytes per entity", perEntity)
+	}
+}
+
+func TestFilter_Changed_Should_Match_Entities_Changed_In_Current_Tick(t *testing.T) {
+	for name, em := range map[string]interface {
+		ecs.EntityManager
+		AdvanceTick()
+	}{
+		"default":   ecs.NewEntityManager(),
+		"archetype": ecs.NewArchetypeEntityManager(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			e1 := em.NewEntity([]ecs.Component{&mockComponent{name: "position", mask: maskPosition}})
+			e2 := em.NewEntity([]ecs.Component{&mockComponent{name: "position", mask: maskPosition}})
+			changed := ecs.NewFilter().Changed(maskPosition)
+			q := em.Query(changed)
+			if len(em.FilterBy(changed)) != 2 {
+				t.Errorf("Added entities should be changed, but got %d", len(em.FilterBy(changed)))
+			}
+			em.AdvanceTick()
+			e2.Set(&mockComponent{name: "position", mask: maskPosition})
+			filtered := em.FilterBy(changed)
+			if len(filtered) != 1 || filtered[0] != e2 {
+				t.Errorf("Only the replaced entity should be changed, but got %d", len(filtered))
+			}
+			count := 0
+			for range q.All() {
+				count++
+			}
+			if count != 1 || q.Len() != 2 {
+				t.Errorf("Query should yield one changed entity out of two, but got %d", count)
+			}
+			if len(em.FilterBy(changed.Since(1))) != 2 {
+				t.Error("Filter should match all the entities changed since the first tick")
+			}
+			e1.Add(&mockComponent{name: "velocity", mask: maskVelocity})
+			added := 0
+			for range em.EachBy(ecs.NewFilter().Added(maskVelocity)) {
+				added++
+			}
+			if added != 1 {
+				t.Errorf("EachBy should yield one added entity, but got %d", added)
+			}
+		})
+	}
+}
+
+func TestDefaultEngine_Tick_Should_Advance_World_Tick(t *testing.T) {
+	em := ecs.NewEntityManager()
+	engine := ecs.NewDefaultEngine(em, ecs.NewSystemManager())
+	engine.Tick()
+	engine.Tick()
+	if em.Tick() != 3 {
+		t.Errorf("World tick should be 3, but got %d", em.Tick())
+	}
+}
+
+func TestDefaultEngine_Tick_Should_Match_Changes_Since_System_Ran_Last(t *testing.T) {
+	for name, parallel := range map[string][]ecs.EngineOption{"sequential": nil, "parallel": {ecs.WithParallelSystems()}} {
+		t.Run(name, func(t *testing.T) {
+			em := ecs.NewEntityManager()
+			e := em.NewEntity([]ecs.Component{&mockComponent{name: "position", mask: maskPosition}})
+			reader := &mockupChangeSystem{}
+			writer := &mockupChangeSystem{mark: e, marks: 1}
+			sm := ecs.NewSystemManager()
+			sm.Add(reader, writer)
+			engine := ecs.NewDefaultEngine(em, sm, parallel...)
+			engine.Setup()
+			for range 3 {
+				engine.Tick()
+			}
+			if !slices.Equal(reader.found, []int{1, 1, 0}) {
+				t.Errorf("Reader should find the change of the writer processed after it, but got %v", reader.found)
+			}
+			if !slices.Equal(writer.found, []int{1, 0, 0}) {
+				t.Errorf("Writer should not find its own change, but got %v", writer.found)
+			}
+		})
+	}
+}
+
+func TestQuery2_All_Should_Only_Yield_Changed_Components(t *testing.T) {
+	em := ecs.NewArchetypeEntityManager()
+	e1 := em.NewEntity([]ecs.Component{&health{value: 1}, &mana{value: 1}})
+	em.NewEntity([]ecs.Component{&health{value: 2}, &mana{value: 2}})
+	q := ecs.NewQuery2[*health, *mana](em, ecs.NewFilter().Changed(ecs.MaskOf[health]()))
+	em.AdvanceTick()
+	ecs.MarkChanged[*health](e1)
+	count := 0
+	for h := range q.All() {
+		if h.value != 1 {
+			t.Errorf("Query should yield the changed health, but got %d", h.value)
+		}
+		count++
+	}
+	if count != 1 {
+		t.Errorf("Query should yield one entity, but got %d", count)
+	}
+}
+
+/*
+       _   _ _
+ _   _| |_(_) |___
+| | | | __| | / __|
+| |_| | |_| | \__ \
+ \__,_|\__|_|_|___/
+*/
+
+// mockupChangeSystem counts the changed positions per tick and marks the position of an entity.
+type mockupChangeSystem struct {
+	mark  *ecs.Entity
+	marks int
+	found []int
+}
+
+func (s *mockupChangeSystem) Process(entityManager ecs.EntityManager) (state int) {
+	s.found = append(s.found, len(entityManager.FilterBy(ecs.NewFilter().Changed(maskPosition))))
+	if s.marks > 0 {
+		s.mark.MarkChanged(maskPosition)
+		s.marks--
+	}
+	return ecs.StateEngineContinue
+}
+
+func (s *mockupChangeSystem) Setup() {}
+
+func (s *mockupChangeSystem) Teardown() {}
@@ -0,0 +1,17 @@
+package ecs
+
+import "time"
+
+// Clock returns the current time, which is used by the Engine to measure the DeltaTime.
+// It can be replaced by WithClock, e.g. to control the time in tests.
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock returns the time of the system.
+type systemClock struct{}
+
+// Now returns the current local time.
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
@@ -0,0 +1,121 @@
+package ecs
+
+import "sync"
+
+// commandKind is the kind of structural change recorded by a CommandBuffer.
+type commandKind int
+
+const (
+	commandSpawn commandKind = iota
+	commandDespawn
+	commandAdd
+	commandRemove
+	commandRemoveBit
+)
+
+// command is a single structural change recorded by a CommandBuffer.
+type command struct {
+	kind       commandKind
+	entity     *Entity
+	components []Component
+	mask       uint64
+	bit        uint
+}
+
+// CommandBuffer records structural changes, which are applied later by Flush.
+// It allows systems to spawn and despawn entities or to add and remove Components
+// while iterating over the entities without modifying the iterated slices.
+// The Engine flushes its CommandBuffer after each System.
+// Commands can be recorded concurrently, e.g. inside ParallelEach,
+// but then their order and the order of applying them is not deterministic.
+type CommandBuffer struct {
+	mutex    sync.Mutex
+	commands []command
+	spare    []command
+}
+
+// NewCommandBuffer creates a new CommandBuffer and returns its address.
+func NewCommandBuffer() *CommandBuffer {
+	return &CommandBuffer{
+		commands: make([]command, 0),
+	}
+}
+
+// Spawn records the creation of an entity with the given Components.
+// The returned entity gets its Id when the CommandBuffer is flushed.
+func (b *CommandBuffer) Spawn(components ...Component) *Entity {
+	e := NewEntity(components)
+	b.record(command{kind: commandSpawn, entity: e})
+	return e
+}
+
+// Despawn records the removal of the entity.
+// An entity, which does not belong to the flushed EntityManager, is ignored.
+func (b *CommandBuffer) Despawn(entity *Entity) {
+	b.record(command{kind: commandDespawn, entity: entity})
+}
+
+// AddComponent records adding the Components to the entity.
+func (b *CommandBuffer) AddComponent(entity *Entity, components ...Component) {
+	b.record(command{kind: commandAdd, entity: entity, components: components})
+}
+
+// RemoveComponent records removing the Component of the mask from the entity.
+func (b *CommandBuffer) RemoveComponent(entity *Entity, mask uint64) {
+	b.record(command{kind: commandRemove, entity: entity, mask: mask})
+}
+
+// RemoveComponentBit records removing the ComponentWithBit of the bit from the entity.
+func (b *CommandBuffer) RemoveComponentBit(entity *Entity, bit uint) {
+	b.record(command{kind: commandRemoveBit, entity: entity, bit: bit})
+}
+
+// Len returns the number of recorded commands.
+func (b *CommandBuffer) Len() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return len(b.commands)
+}
+
+// record appends the command.
+func (b *CommandBuffer) record(c command) {
+	b.mutex.Lock()
+	b.commands = append(b.commands, c)
+	b.mutex.Unlock()
+}
+
+// Flush applies the recorded commands to the EntityManager in the order they were recorded
+// and clears the CommandBuffer. Commands recorded while flushing are kept for the next Flush.
+func (b *CommandBuffer) Flush(em EntityManager) {
+	b.mutex.Lock()
+	if len(b.commands) == 0 {
+		b.mutex.Unlock()
+		return
+	}
+
+	// Swap the buffers, so that flushing every frame allocates nothing.
+	commands := b.commands
+	b.commands = b.spare[:0]
+	b.spare = nil
+	b.mutex.Unlock()
+
+	for _, c := range commands {
+		switch c.kind {
+		case commandSpawn:
+			em.Add(c.entity)
+		case commandDespawn:
+			em.Remove(c.entity)
+		case commandAdd:
+			c.entity.Add(c.components...)
+		case commandRemove:
+			c.entity.Remove(c.mask)
+		case commandRemoveBit:
+			c.entity.RemoveBit(c.bit)
+		}
+	}
+	clear(commands)
+	b.mutex.Lock()
+	b.spare = commands
+	b.mutex.Unlock()
+}
@@ -0,0 +1,140 @@
+package ecs_test
+
+import (
+	"testing"
+
+	"github.com/bolom009/ecs"
+)
+
+func TestCommandBuffer_Flush_Should_Apply_Commands_In_Order(t *testing.T) {
+	for name, em := range map[string]ecs.EntityManager{
+		"default":   ecs.NewEntityManager(),
+		"archetype": ecs.NewArchetypeEntityManager(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			e1 := em.NewEntity([]ecs.Component{&mockComponent{name: "position", mask: 1}})
+			e2 := em.NewEntity([]ecs.Component{&mockComponent{name: "position", mask: 1}})
+			cb := ecs.NewCommandBuffer()
+			spawned := cb.Spawn(&mockComponent{name: "position", mask: 1})
+			cb.AddComponent(spawned, &mockComponent{name: "velocity", mask: 2})
+			cb.Despawn(e1)
+			cb.AddComponent(e2, &mockComponent{name: "velocity", mask: 2})
+			cb.RemoveComponent(e2, 1)
+			if cb.Len() != 5 {
+				t.Errorf("CommandBuffer should have five commands, but got %d", cb.Len())
+			}
+			if len(em.Entities()) != 2 || spawned.Id != 0 {
+				t.Error("Commands should not be applied before Flush")
+			}
+			cb.Flush(em)
+			if cb.Len() != 0 {
+				t.Errorf("CommandBuffer should be empty after Flush, but got %d", cb.Len())
+			}
+			if em.IsAlive(e1.Id) {
+				t.Error("Despawned entity should not be alive")
+			}
+			if !em.IsAlive(spawned.Id) || spawned.Mask() != 3 {
+				t.Errorf("Spawned entity should be alive with mask 3, but got %d", spawned.Mask())
+			}
+			if e2.Mask() != 2 {
+				t.Errorf("Entity should have mask 2, but got %d", e2.Mask())
+			}
+			if len(em.FilterByMask(2)) != 2 {
+				t.Errorf("EntityManager should return two entities, but got %d", len(em.FilterByMask(2)))
+			}
+		})
+	}
+}
+
+func TestCommandBuffer_Despawn_Should_Remove_Spawned_Entity(t *testing.T) {
+	em := ecs.NewEntityManager()
+	cb := ecs.NewCommandBuffer()
+	e := cb.Spawn()
+	cb.Despawn(e)
+	cb.Flush(em)
+	if len(em.Entities()) != 0 {
+		t.Errorf("EntityManager should have no entity, but got %d", len(em.Entities()))
+	}
+}
+
+func TestCommandBuffer_Despawn_Should_Ignore_Entity_Of_Other_Manager(t *testing.T) {
+	em := ecs.NewEntityManager()
+	other := ecs.NewEntityManager()
+	e := em.NewEntity(nil)
+	foreign := other.NewEntity(nil)
+	cb := ecs.NewCommandBuffer()
+	cb.Despawn(foreign)
+	cb.Flush(em)
+	if em.Get(e.Id) != e || other.Get(foreign.Id) != foreign {
+		t.Error("Flush should not remove the entity with the same Id of another EntityManager")
+	}
+}
+
+func TestDefaultEngine_Tick_Should_Flush_CommandBuffer_After_Each_System(t *testing.T) {
+	em := ecs.NewEntityManager()
+	em.Add(generateEntities(10)...)
+	cb := ecs.NewCommandBuffer()
+	despawn := &mockupDespawnSystem{commands: cb}
+	count := &mockupCountSystem{}
+	sm := ecs.NewSystemManager()
+	sm.Add(despawn, count)
+	engine := ecs.NewDefaultEngine(em, sm, ecs.WithCommandBuffer(cb))
+	engine.Tick()
+	if despawn.visited != 10 {
+		t.Errorf("System should visit all the entities, but got %d", despawn.visited)
+	}
+	if count.count != 0 {
+		t.Errorf("Next system should see no entity, but got %d", count.count)
+	}
+}
+
+func BenchmarkCommandBuffer_Flush(b *testing.B) {
+	em := ecs.NewEntityManager()
+	cb := ecs.NewCommandBuffer()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for b.Loop() {
+		for i := 0; i < 100; i++ {
+			cb.Despawn(cb.Spawn(&position{x: 1, y: 1}))
+		}
+		cb.Flush(em)
+	}
+}
+
+/*
+       _   _ _
+ _   _| |_(_) |___
+| | | | __| | / __|
+| |_| | |_| | \__ \
+ \__,_|\__|_|_|___/
+*/
+
+// mockupDespawnSystem despawns all the entities while iterating over them.
+type mockupDespawnSystem struct {
+	commands *ecs.CommandBuffer
+	visited  int
+}
+
+func (s *mockupDespawnSystem) Process(em ecs.EntityManager) (state int) {
+	for _, e := range em.Entities() {
+		s.visited++
+		s.commands.Despawn(e)
+	}
+	return ecs.StateEngineContinue
+}
+func (s *mockupDespawnSystem) Setup()    {}
+func (s *mockupDespawnSystem) Teardown() {}
+
+// mockupCountSystem counts the entities.
+type mockupCountSystem struct {
+	count int
+}
+
+func (s *mockupCountSystem) Process(em ecs.EntityManager) (state int) {
+	s.count = len(em.Entities())
+	return ecs.StateEngineContinue
+}
+func (s *mockupCountSystem) Setup()    {}
+func (s *mockupCountSystem) Teardown() {}
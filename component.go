@@ -10,3 +10,20 @@ type ComponentWithName interface {
 	Component
 	Name() string
 }
+
+// ComponentWithBit is used by Components, which do not fit into the 64 bits of Mask().
+// Bit returns the position of the Component in a Bitset. If the position is beyond 63,
+// Mask() is ignored and the Component can only be accessed by Entity.GetBit().
+type ComponentWithBit interface {
+	Component
+	Bit() uint
+}
+
+// wideBit returns the position of a ComponentWithBit beyond the first 64 bits.
+func wideBit(c Component) (uint, bool) {
+	if cb, ok := c.(ComponentWithBit); ok && cb.Bit() >= 64 {
+		return cb.Bit(), true
+	}
+
+	return 0, false
+}
@@ -1,20 +1,48 @@
 package ecs
 
+import "context"
+
+// States returned by a System to the Engine.
 const (
+	// StateEngineContinue continues with the next System.
 	StateEngineContinue = 0
-	StateEngineStop     = 1
+	// StateEngineStop stops the Engine after the System.
+	StateEngineStop = 1
+	// StateEnginePause pauses the Engine after the System, see Engine.Pause.
+	StateEnginePause = 2
+	// StateEngineSkip skips the remaining systems of the current tick.
+	StateEngineSkip = 3
+	// StateEngineRestart skips the remaining systems of the current tick
+	// and calls Teardown() and Setup() for each System afterwards.
+	StateEngineRestart = 4
 )
 
 // Engine handles the stages Setup(), Run() and Teardown() for all the systems.
+// The structural changes recorded into its CommandBuffer are applied after each System.
 type Engine interface {
 	// Run calls the Process() method for each System
 	// until ShouldEngineStop is set to true.
 	Run()
-	// Setup calls the Setup() method for each System
-	// and initializes ShouldEngineStop and ShouldEnginePause with false.
+	// RunContext calls the Process() method for each System until a System returns
+	// StateEngineStop or the context is done, which is checked between the ticks.
+	// It calls Teardown() before returning and returns the cause of the cancellation,
+	// the fatal SystemError or nil if a System stopped the Engine.
+	RunContext(ctx context.Context) error
+	// Err returns the SystemErrors recorded since the last Setup joined by errors.Join
+	// or nil if no System failed. A SystemError names the failed System.
+	Err() error
+	// Pause stops processing the systems, which are not essential, until Resume is called.
+	// Essential systems are set by WithEssentialSystems.
+	Pause()
+	// Resume continues processing all the systems after Pause.
+	Resume()
+	// Paused reports whether the Engine is paused.
+	Paused() bool
+	// Setup calls the Setup() method for each System and resumes a paused Engine.
 	Setup()
 	// Teardown calls the Teardown() method for each System.
 	Teardown()
 	// Tick calls the Process() method for each System exactly once
+	// and swaps the Events and advances the world tick of the EntityManager afterwards.
 	Tick()
 }
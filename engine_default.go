@@ -1,54 +1,631 @@
 package ecs
 
+import (
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
 // defaultEngine is simple a composition of an defaultEntityManager and a defaultSystemManager.
 type defaultEngine struct {
 	entityManager EntityManager
+	// ticker is the entityManager, if it counts the world ticks.
+	ticker        ticker
 	systemManager SystemManager
+	commands      *CommandBuffer
+	events        *EventBus
+	// frame is passed to each ContextSystem and reused for each tick.
+	frame Context
+	clock Clock
+	last  time.Time
+	// fixed contains the systems processed with the fixed step.
+	fixed       SystemManager
+	step        time.Duration
+	maxSteps    int
+	accumulator time.Duration
+	// parallel processes the stages of the SystemManager in parallel.
+	parallel bool
+	// frames, buffers, states and results are used by the systems of a parallel stage.
+	frames  []Context
+	buffers []*CommandBuffer
+	states  []int
+	results []error
+	// errs contains the SystemErrors since the last Setup.
+	errs []error
+	// fatal is the first SystemError, which stopped the Engine.
+	fatal error
+	// recovery is the RecoverPolicy of the systems without their own one in policies.
+	recovery RecoverPolicy
+	policies map[any]RecoverPolicy
+	handler  PanicHandler
+	// disabled contains the systems disabled by RecoverDisable since the last Setup.
+	disabled map[System]bool
+	// essential contains the systems, which are processed while the Engine is paused.
+	essential map[any]bool
+	paused    atomic.Bool
+	// runs contains the stamp of the last run of each System.
+	runs map[System]uint64
+}
+
+// EngineOption configures the Engine created by NewDefaultEngine.
+type EngineOption func(e *defaultEngine)
+
+// WithCommandBuffer sets the CommandBuffer, which is flushed after each System.
+// Systems record their structural changes into the same CommandBuffer.
+func WithCommandBuffer(commands *CommandBuffer) EngineOption {
+	return func(e *defaultEngine) {
+		e.commands = commands
+	}
+}
+
+// WithEventBus sets the EventBus, which Events are swapped after each tick.
+// Systems send and read their Events through the same EventBus.
+func WithEventBus(events *EventBus) EngineOption {
+	return func(e *defaultEngine) {
+		e.events = events
+	}
+}
+
+// WithParallelSystems processes the systems of each stage of the SystemManager in parallel
+// and waits for all of them before the next stage begins.
+// Each ContextSystem of a parallel stage gets its own CommandBuffer in its Context,
+// which are flushed in the order of the systems to keep the result deterministic.
+// Systems of the same stage may share a CommandBuffer, but then the order of its commands
+// is not deterministic. They must not share Events.
+// The EntityManagers of this package can be filtered by the systems of a stage concurrently.
+// The Engine stops after the stage, in which a System returned StateEngineStop.
+// A panic, which is not recovered by a RecoverPolicy, is raised again in the goroutine calling Tick.
+func WithParallelSystems() EngineOption {
+	return func(e *defaultEngine) {
+		e.parallel = true
+	}
+}
+
+// WithClock sets the Clock, which is used to measure the DeltaTime.
+func WithClock(clock Clock) EngineOption {
+	return func(e *defaultEngine) {
+		e.clock = clock
+	}
+}
+
+// WithFixedTimestep processes the systems of the fixed SystemManager with a fixed step,
+// e.g. 60 times per second for a physics simulation, before the other systems of each tick.
+// The elapsed time is accumulated and consumed in steps, at most maxSteps per tick;
+// the time of further steps is dropped to catch up. The remaining time is passed
+// as Alpha in the Context to interpolate between the fixed steps while rendering.
+// Like the other systems, only the essential fixed systems are processed while the Engine is paused.
+// It panics if the step is not positive.
+func WithFixedTimestep(step time.Duration, maxSteps int, fixed SystemManager) EngineOption {
+	if step <= 0 {
+		panic("ecs: non-positive fixed step for WithFixedTimestep")
+	}
+
+	return func(e *defaultEngine) {
+		e.step = step
+		e.maxSteps = max(maxSteps, 1)
+		e.fixed = fixed
+	}
+}
+
+// WithRecoverPolicy sets the RecoverPolicy of the systems, which are Systems, ContextSystems or ErrorSystems,
+// or the RecoverPolicy of all the other systems, if no system is given.
+// The systems must be comparable, e.g. pointers.
+func WithRecoverPolicy(policy RecoverPolicy, systems ...any) EngineOption {
+	return func(e *defaultEngine) {
+		if len(systems) == 0 {
+			e.recovery = policy
+			return
+		}
+		if e.policies == nil {
+			e.policies = make(map[any]RecoverPolicy)
+		}
+		for _, system := range systems {
+			e.policies[system] = policy
+		}
+	}
+}
+
+// WithEssentialSystems marks the systems, which are Systems, ContextSystems or ErrorSystems,
+// as essential, so that they are processed while the Engine is paused, e.g. the input and rendering.
+// The systems must be comparable, e.g. pointers.
+func WithEssentialSystems(systems ...any) EngineOption {
+	return func(e *defaultEngine) {
+		if e.essential == nil {
+			e.essential = make(map[any]bool)
+		}
+		for _, system := range systems {
+			e.essential[system] = true
+		}
+	}
+}
+
+// WithPanicHandler sets the PanicHandler, which is called for each panic recovered by a RecoverPolicy.
+func WithPanicHandler(handler PanicHandler) EngineOption {
+	return func(e *defaultEngine) {
+		e.handler = handler
+	}
 }
 
 // Run calls the Process() method for each System
 // until ShouldEngineStop is set to true.
 func (e *defaultEngine) Run() {
-	shouldStop := false
-	for !shouldStop {
-		for _, system := range e.systemManager.Systems() {
-			state := system.Process(e.entityManager)
-			if state == StateEngineStop {
-				shouldStop = true
-				break
-			}
+	for !e.tick() {
+	}
+}
+
+// RunContext calls the Process() method for each System until a System returns
+// StateEngineStop or the context is done, which is checked between the ticks.
+// It calls Teardown() before returning and returns the cause of the cancellation,
+// the fatal SystemError or nil if a System stopped the Engine.
+// The context is passed to each ContextSystem in its Context.
+func (e *defaultEngine) RunContext(ctx context.Context) error {
+	defer e.Teardown()
+
+	parent := e.frame.Context
+	e.frame.Context = ctx
+	defer func() { e.frame.Context = parent }()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		default:
+		}
+		if e.tick() {
+			return e.fatal
 		}
 	}
 }
 
 // Tick calls the Process() method for each System exactly once
+// and swaps the Events and advances the world tick of the EntityManager afterwards.
 func (e *defaultEngine) Tick() {
-	for _, system := range e.systemManager.Systems() {
-		if state := system.Process(e.entityManager); state == StateEngineStop {
+	e.tick()
+}
+
+// Pause stops processing the systems, which are not essential, until Resume is called.
+// It can be called by a System or by another goroutine.
+func (e *defaultEngine) Pause() {
+	e.paused.Store(true)
+}
+
+// Resume continues processing all the systems after Pause.
+func (e *defaultEngine) Resume() {
+	e.paused.Store(false)
+}
+
+// Paused reports whether the Engine is paused.
+func (e *defaultEngine) Paused() bool {
+	return e.paused.Load()
+}
+
+// Err returns the SystemErrors recorded since the last Setup joined by errors.Join
+// or nil if no System failed.
+func (e *defaultEngine) Err() error {
+	return errors.Join(e.errs...)
+}
+
+// tick processes each System once and reports whether a System returned StateEngineStop.
+// It does not process any System after a fatal SystemError until the next Setup.
+// The systems are restarted after the tick, in which a System returned StateEngineRestart.
+func (e *defaultEngine) tick() (shouldStop bool) {
+	if e.fatal != nil {
+		return true
+	}
+	e.begin()
+	state := StateEngineContinue
+	if e.fixed != nil {
+		state = e.fixedUpdate()
+	}
+	if state == StateEngineContinue {
+		state = e.update(e.systemManager)
+	}
+	e.events.swap()
+	if e.ticker != nil {
+		e.ticker.AdvanceTick()
+	}
+	if state == StateEngineRestart {
+		e.Teardown()
+		e.Setup()
+	}
+	return state == StateEngineStop
+}
+
+// fixedUpdate processes the fixed systems for each step of the accumulated time
+// and sets the Alpha of the remaining time.
+func (e *defaultEngine) fixedUpdate() (state int) {
+	deltaTime := e.frame.DeltaTime
+	e.accumulator += deltaTime
+	e.frame.DeltaTime = e.step
+	for steps := 0; e.accumulator >= e.step && state == StateEngineContinue; steps++ {
+		if steps == e.maxSteps {
+			// Drop the steps, which cannot be caught up.
+			e.accumulator %= e.step
 			break
 		}
+		state = e.update(e.fixed)
+		e.accumulator -= e.step
+	}
+	e.frame.DeltaTime = deltaTime
+	e.frame.Alpha = float64(e.accumulator) / float64(e.step)
+	return state
+}
+
+// update processes the systems of the SystemManager
+// and returns the state, which ends the tick, or StateEngineContinue.
+func (e *defaultEngine) update(systemManager SystemManager) (state int) {
+	if e.parallel {
+		return e.processStages(systemManager)
+	}
+
+	return e.processSystems(systemManager)
+}
+
+// processSystems processes the systems one after another.
+func (e *defaultEngine) processSystems(systemManager SystemManager) (state int) {
+	systems := systemManager.Systems()
+	for i, system := range systems {
+		if e.skips(system) {
+			continue
+		}
+		e.beginRun(systems[i : i+1])
+		state, err := e.process(system, &e.frame)
+		e.endRun()
+		e.commands.Flush(e.entityManager)
+		if state = e.result(system, state, err); state != StateEngineContinue {
+			return state
+		}
+	}
+	return StateEngineContinue
+}
+
+// processStages processes the stages one after another and the systems of each stage in parallel.
+func (e *defaultEngine) processStages(systemManager SystemManager) (state int) {
+	for _, stage := range systemManager.Stages() {
+		if len(stage) == 1 {
+			if e.skips(stage[0]) {
+				continue
+			}
+			var err error
+			e.beginRun(stage)
+			state, err = e.process(stage[0], &e.frame)
+			e.endRun()
+			e.commands.Flush(e.entityManager)
+			state = e.result(stage[0], state, err)
+		} else {
+			state = e.processStage(stage)
+		}
+		if state != StateEngineContinue {
+			return state
+		}
 	}
+	return StateEngineContinue
 }
 
-// Setup calls the Setup() method for each System
-// and initializes ShouldEngineStop and ShouldEnginePause with false.
+// processStage processes the systems of a stage in parallel
+// and applies their commands in the order of the systems afterwards.
+// A panic propagated by the RecoverPolicy of a System is raised again in the calling goroutine.
+func (e *defaultEngine) processStage(stage []System) (state int) {
+	for len(e.buffers) < len(stage) {
+		e.buffers = append(e.buffers, NewCommandBuffer())
+	}
+	e.frames = append(e.frames[:0], make([]Context, len(stage))...)
+	e.states = append(e.states[:0], make([]int, len(stage))...)
+	e.results = append(e.results[:0], make([]error, len(stage))...)
+
+	e.beginRun(stage)
+	var (
+		wg     sync.WaitGroup
+		once   sync.Once
+		failed any
+	)
+	for i, system := range stage {
+		if e.skips(system) {
+			continue
+		}
+		e.frames[i] = e.frame
+		e.frames[i].Commands = e.buffers[i]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					once.Do(func() { failed = r })
+				}
+			}()
+			e.states[i], e.results[i] = e.process(system, &e.frames[i])
+		}()
+	}
+	wg.Wait()
+	e.endRun()
+	if failed != nil {
+		panic(failed)
+	}
+
+	for i, system := range stage {
+		e.buffers[i].Flush(e.entityManager)
+		state = stronger(state, e.result(system, e.states[i], e.results[i]))
+	}
+	e.commands.Flush(e.entityManager)
+	return state
+}
+
+// result records the error of the System and returns the state, which ends the tick,
+// or StateEngineContinue. StateEnginePause pauses the Engine for the following systems.
+func (e *defaultEngine) result(system System, state int, err error) int {
+	if e.fail(system, PhaseProcess, err) {
+		return StateEngineStop
+	}
+
+	switch state {
+	case StateEngineStop, StateEngineSkip, StateEngineRestart:
+		return state
+	case StateEnginePause:
+		e.Pause()
+	}
+	return StateEngineContinue
+}
+
+// stronger returns the state, which ends the tick with the higher priority:
+// StateEngineStop before StateEngineRestart before StateEngineSkip.
+func stronger(a, b int) int {
+	for _, state := range [...]int{StateEngineStop, StateEngineRestart, StateEngineSkip} {
+		if a == state || b == state {
+			return state
+		}
+	}
+	return StateEngineContinue
+}
+
+// skips reports whether the System is disabled or not essential while the Engine is paused.
+func (e *defaultEngine) skips(system System) bool {
+	return e.isDisabled(system) || e.Paused() && !e.isEssential(system)
+}
+
+// isEssential reports whether the System is processed while the Engine is paused.
+func (e *defaultEngine) isEssential(system System) bool {
+	if len(e.essential) == 0 {
+		return false
+	}
+
+	s := adapted(system)
+	return isComparable(s) && e.essential[s]
+}
+
+// begin updates the Context for the current frame.
+func (e *defaultEngine) begin() {
+	now := e.clock.Now()
+	e.frame.DeltaTime = 0
+	if !e.last.IsZero() {
+		e.frame.DeltaTime = now.Sub(e.last)
+	}
+	e.last = now
+	e.frame.Tick = worldTick(e.entityManager)
+}
+
+// beginRun starts the run of the systems, which are not skipped, so that Added and Changed
+// match the Components changed since they ran last. The systems of a parallel stage share
+// their earliest last run. Systems, which are not comparable, find the changes of the current tick.
+func (e *defaultEngine) beginRun(systems []System) {
+	if e.ticker == nil {
+		return
+	}
+
+	last := uint64(math.MaxUint64)
+	for _, system := range systems {
+		if e.skips(system) {
+			continue
+		}
+		if !isComparable(system) {
+			last = min(last, stampOf(e.ticker.Tick(), 0)-1)
+			continue
+		}
+		last = min(last, e.runs[system])
+	}
+	stamp := e.ticker.beginRun(last)
+	for _, system := range systems {
+		if !e.skips(system) && isComparable(system) {
+			e.runs[system] = stamp
+		}
+	}
+}
+
+// endRun finishes the run of the systems started by beginRun.
+func (e *defaultEngine) endRun() {
+	if e.ticker != nil {
+		e.ticker.endRun()
+	}
+}
+
+// process calls ProcessContext for a ContextSystem, Process with the Context for an ErrorSystem
+// and Process for any other System. ErrEngineStop is returned as StateEngineStop.
+// A panic is returned as PanicError, if the RecoverPolicy of the System recovers it.
+func (e *defaultEngine) process(system System, frame *Context) (state int, err error) {
+	if e.policy(system) == RecoverPropagate {
+		return e.invoke(system, frame)
+	}
+
+	err = guard(func() (err error) {
+		state, err = e.invoke(system, frame)
+		return err
+	})
+	return state, err
+}
+
+// invoke calls the process method matching the type of the System.
+func (e *defaultEngine) invoke(system System, frame *Context) (state int, err error) {
+	switch s := system.(type) {
+	case *contextSystem:
+		return s.ProcessContext(frame), nil
+	case *errorSystem:
+		err = s.system.Process(frame)
+		if errors.Is(err, ErrEngineStop) {
+			return StateEngineStop, nil
+		}
+		return StateEngineContinue, err
+	}
+
+	return system.Process(e.entityManager), nil
+}
+
+// protect calls fn and returns a panic as PanicError, if the RecoverPolicy of the System recovers it.
+func (e *defaultEngine) protect(system System, fn func() error) error {
+	if e.policy(system) == RecoverPropagate {
+		return fn()
+	}
+
+	return guard(fn)
+}
+
+// policy returns the RecoverPolicy of the System.
+func (e *defaultEngine) policy(system System) RecoverPolicy {
+	if len(e.policies) > 0 {
+		if s := adapted(system); isComparable(s) {
+			if policy, ok := e.policies[s]; ok {
+				return policy
+			}
+		}
+	}
+	return e.recovery
+}
+
+// fail records the error of the System as a SystemError and reports whether the Engine should stop,
+// which is the case for a fatal error or any error during the setup.
+// A panic recovered during the process is handled by the RecoverPolicy of the System.
+func (e *defaultEngine) fail(system System, phase string, err error) (shouldStop bool) {
+	if err == nil {
+		return false
+	}
+
+	failed := e.record(system, phase, err)
+	if _, ok := err.(*PanicError); ok && phase == PhaseProcess {
+		switch e.policy(system) {
+		case RecoverDisable:
+			e.disable(system)
+		case RecoverRestart:
+			e.restart(system)
+		case RecoverAbort:
+			err = Fatal(err)
+		}
+	}
+	if e.fatal == nil && (phase == PhaseSetup || IsFatal(err)) {
+		e.fatal = failed
+	}
+	return e.fatal != nil
+}
+
+// record appends the error of the System as a SystemError and reports a recovered panic to the PanicHandler.
+func (e *defaultEngine) record(system System, phase string, err error) *SystemError {
+	failed := &SystemError{
+		System: adapted(system),
+		Name:   systemName(system),
+		Phase:  phase,
+		Tick:   worldTick(e.entityManager),
+		Err:    err,
+	}
+	e.errs = append(e.errs, failed)
+	if recovered, ok := err.(*PanicError); ok && e.handler != nil {
+		e.handler(failed, recovered)
+	}
+	return failed
+}
+
+// restart calls Teardown and Setup of the System and disables it if one of them fails.
+func (e *defaultEngine) restart(system System) {
+	phase := PhaseTeardown
+	err := guard(func() error { return teardown(system) })
+	if err == nil {
+		phase = PhaseSetup
+		err = guard(func() error { return setup(system) })
+	}
+	if err != nil {
+		e.record(system, phase, err)
+		e.disable(system)
+	}
+}
+
+// disable stops processing the System until the next Setup.
+// A System, which is not comparable, cannot be disabled and is processed again.
+func (e *defaultEngine) disable(system System) {
+	if !isComparable(system) {
+		return
+	}
+	if e.disabled == nil {
+		e.disabled = make(map[System]bool)
+	}
+	e.disabled[system] = true
+}
+
+// isDisabled reports whether the System was disabled by its RecoverPolicy.
+func (e *defaultEngine) isDisabled(system System) bool {
+	return len(e.disabled) > 0 && isComparable(system) && e.disabled[system]
+}
+
+// Setup calls the Setup() method for each System and resumes a paused Engine.
+// It clears the recorded SystemErrors and enables the disabled systems.
+// If an ErrorSystem fails or the constraints of the systems contain a cycle,
+// the Engine does not process any System until Setup is called again.
 func (e *defaultEngine) Setup() {
-	for _, sys := range e.systemManager.Systems() {
-		sys.Setup()
+	e.errs = nil
+	e.fatal = nil
+	e.Resume()
+	clear(e.disabled)
+	for _, systemManager := range []SystemManager{e.systemManager, e.fixed} {
+		if systemManager == nil {
+			continue
+		}
+		if _, err := systemManager.Order(); err != nil && e.fatal == nil {
+			e.fatal = Fatal(err)
+			e.errs = append(e.errs, e.fatal)
+		}
+	}
+	for _, sys := range e.systems() {
+		e.fail(sys, PhaseSetup, e.protect(sys, func() error { return setup(sys) }))
 	}
 }
 
-// Teardown calls the Teardown() method for each System.
+// Teardown calls the Teardown() method for each System including the disabled ones.
+// The errors of the ErrorSystems and the recovered panics are recorded, see Err.
 func (e *defaultEngine) Teardown() {
-	for _, sys := range e.systemManager.Systems() {
-		sys.Teardown()
+	for _, sys := range e.systems() {
+		e.fail(sys, PhaseTeardown, e.protect(sys, func() error { return teardown(sys) }))
 	}
 }
 
+// systems returns the fixed systems followed by the other systems.
+func (e *defaultEngine) systems() []System {
+	if e.fixed == nil {
+		return e.systemManager.Systems()
+	}
+
+	return append(slices.Clone(e.fixed.Systems()), e.systemManager.Systems()...)
+}
+
 // NewDefaultEngine creates a new Engine and returns its address.
-func NewDefaultEngine(entityManager EntityManager, systemManager SystemManager) Engine {
-	return &defaultEngine{
+// The structural changes recorded into its CommandBuffer are applied after each System.
+func NewDefaultEngine(entityManager EntityManager, systemManager SystemManager, opts ...EngineOption) Engine {
+	e := &defaultEngine{
 		entityManager: entityManager,
 		systemManager: systemManager,
+		commands:      NewCommandBuffer(),
+		events:        NewEventBus(),
+		clock:         systemClock{},
+		runs:          make(map[System]uint64),
+	}
+	e.ticker, _ = entityManager.(ticker)
+	for _, opt := range opts {
+		opt(e)
+	}
+	e.frame = Context{
+		Context:   context.Background(),
+		World:     entityManager,
+		Commands:  e.commands,
+		Events:    e.events,
+		Resources: entityManager.Resources(),
 	}
+	return e
 }
@@ -1,7 +1,12 @@
 package ecs_test
 
 import (
+	"context"
+	"errors"
+	"iter"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/bolom009/ecs"
 )
@@ -49,6 +54,276 @@ func TestDefaultEngine_Tick_Twice_Should_Increase_Counter_By_Two(t *testing.T) {
 	}
 }
 
+func TestDefaultEngine_Tick_Should_Process_Stage_In_Parallel(t *testing.T) {
+	em := ecs.NewEntityManager()
+	var barrier sync.WaitGroup
+	barrier.Add(2)
+	s1 := &mockupParallelSystem{barrier: &barrier, mask: 1}
+	s2 := &mockupParallelSystem{barrier: &barrier, mask: 2}
+	sm := ecs.NewSystemManager()
+	sm.Schedule(ecs.AdaptContextSystem(s1), ecs.Reads(4), ecs.Writes(1))
+	sm.Schedule(ecs.AdaptContextSystem(s2), ecs.Reads(4), ecs.Writes(2))
+	engine := ecs.NewDefaultEngine(em, sm, ecs.WithParallelSystems())
+	engine.Tick()
+	if !s1.concurrent || !s2.concurrent {
+		t.Fatal("Systems of the same stage should be processed in parallel")
+	}
+	entities := em.Entities()
+	if len(entities) != 2 || entities[0].Mask() != 1 || entities[1].Mask() != 2 {
+		t.Error("Commands should be applied in the order of the systems")
+	}
+}
+
+func TestDefaultEngine_Tick_Should_Stop_After_Parallel_Stage(t *testing.T) {
+	sm := ecs.NewSystemManager()
+	stop := &mockupSystem{}
+	next := &mockupSystem{}
+	sm.Schedule(stop, ecs.Writes(1))
+	sm.Schedule(&mockupDedicatedSystem{}, ecs.Writes(2))
+	sm.Add(next)
+	engine := ecs.NewDefaultEngine(ecs.NewEntityManager(), sm, ecs.WithParallelSystems())
+	engine.Run()
+	if stop.Counter != 1 || next.Counter != 0 {
+		t.Errorf("Engine should stop after the stage, but got %d and %d", stop.Counter, next.Counter)
+	}
+}
+
+func TestDefaultEngine_Tick_Should_Process_Fixed_Steps(t *testing.T) {
+	clock := &mockupClock{now: time.Unix(0, 0)}
+	fixed := &mockupFrameSystem{}
+	variable := &mockupFrameSystem{}
+	fsm := ecs.NewSystemManager()
+	fsm.AddContext(fixed)
+	sm := ecs.NewSystemManager()
+	sm.AddContext(variable)
+	engine := ecs.NewDefaultEngine(ecs.NewEntityManager(), sm,
+		ecs.WithClock(clock),
+		ecs.WithFixedTimestep(16*time.Millisecond, 3, fsm),
+	)
+	engine.Tick()
+	if len(fixed.deltaTime) != 0 || len(variable.deltaTime) != 1 {
+		t.Fatal("First tick should only process the variable systems")
+	}
+	clock.now = clock.now.Add(40 * time.Millisecond)
+	engine.Tick()
+	if len(fixed.deltaTime) != 2 || fixed.deltaTime[0] != 16*time.Millisecond {
+		t.Fatalf("Fixed systems should be processed twice with the fixed step, but got %v", fixed.deltaTime)
+	}
+	if variable.deltaTime[1] != 40*time.Millisecond || variable.alpha[1] != 0.5 {
+		t.Errorf("Variable system should get the elapsed time and alpha 0.5, but got %v and %v", variable.deltaTime[1], variable.alpha[1])
+	}
+	clock.now = clock.now.Add(time.Second)
+	engine.Tick()
+	if len(fixed.deltaTime) != 5 {
+		t.Errorf("Fixed systems should be processed at most three times per tick, but got %d", len(fixed.deltaTime)-2)
+	}
+	if variable.alpha[2] < 0 || variable.alpha[2] >= 1 {
+		t.Errorf("Alpha should be between 0 and 1 after dropping steps, but got %v", variable.alpha[2])
+	}
+}
+
+func TestWithFixedTimestep_Should_Panic_For_Non_Positive_Step(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("WithFixedTimestep should panic for a zero step")
+		}
+	}()
+	ecs.WithFixedTimestep(0, 1, ecs.NewSystemManager())
+}
+
+func TestDefaultEngine_RunContext_Should_Return_Cause_And_Teardown(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	shutdown := errors.New("shutdown")
+	sys := &mockupCancelSystem{cancel: func() { cancel(shutdown) }, after: 3}
+	sm := ecs.NewSystemManager()
+	sm.AddContext(sys)
+	engine := ecs.NewDefaultEngine(ecs.NewEntityManager(), sm)
+	engine.Setup()
+	err := engine.RunContext(ctx)
+	if !errors.Is(err, shutdown) {
+		t.Errorf("RunContext should return the cause, but got %v", err)
+	}
+	if sys.counter != 3 {
+		t.Errorf("Engine should stop after the tick, in which the context was cancelled, but got %d ticks", sys.counter)
+	}
+	if !sys.teardown {
+		t.Error("RunContext should call Teardown")
+	}
+	if !errors.Is(sys.err, context.Canceled) {
+		t.Errorf("ContextSystem should receive the context, but got %v", sys.err)
+	}
+}
+
+func TestDefaultEngine_RunContext_Should_Return_Nil_If_System_Stops(t *testing.T) {
+	engine, system := prepare()
+	if err := engine.RunContext(context.Background()); err != nil {
+		t.Errorf("RunContext should return nil, but got %v", err)
+	}
+	if system.Counter != 1 {
+		t.Errorf("Counter should be 1, but got %d", system.Counter)
+	}
+}
+
+func TestDefaultEngine_RunContext_Should_Not_Tick_If_Context_Is_Done(t *testing.T) {
+	engine, system := prepare()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := engine.RunContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("RunContext should return context.Canceled, but got %v", err)
+	}
+	if system.Counter != 0 {
+		t.Errorf("Counter should be 0, but got %d", system.Counter)
+	}
+}
+
+func TestDefaultEngine_Pause_Should_Process_Essential_Systems_Only(t *testing.T) {
+	input := &mockupStateSystem{}
+	physics := &mockupStateSystem{}
+	sm := ecs.NewSystemManager()
+	sm.Add(input, physics)
+	engine := ecs.NewDefaultEngine(ecs.NewEntityManager(), sm, ecs.WithEssentialSystems(input))
+	engine.Pause()
+	engine.Tick()
+	if !engine.Paused() || input.processed != 1 || physics.processed != 0 {
+		t.Errorf("Paused Engine should process essential systems only, but got %d and %d calls", input.processed, physics.processed)
+	}
+	engine.Resume()
+	engine.Tick()
+	if engine.Paused() || input.processed != 2 || physics.processed != 1 {
+		t.Errorf("Resumed Engine should process all the systems, but got %d and %d calls", input.processed, physics.processed)
+	}
+}
+
+func TestDefaultEngine_Pause_Should_Skip_Systems_Which_Are_Not_Comparable(t *testing.T) {
+	processed := 0
+	input := &mockupStateSystem{}
+	sm := ecs.NewSystemManager()
+	sm.Add(input, mockupValueSystem{names: []string{"value"}, processed: &processed})
+	engine := ecs.NewDefaultEngine(ecs.NewEntityManager(), sm, ecs.WithEssentialSystems(input))
+	engine.Pause()
+	engine.Tick()
+	engine.Resume()
+	engine.Tick()
+	if input.processed != 2 || processed != 1 {
+		t.Errorf("Paused Engine should skip the system, which is not comparable, but got %d and %d calls", input.processed, processed)
+	}
+}
+
+func TestDefaultEngine_Pause_Should_Process_Essential_Fixed_Systems(t *testing.T) {
+	clock := &mockupClock{now: time.Unix(0, 0)}
+	network := &mockupFrameSystem{}
+	physics := &mockupFrameSystem{}
+	fsm := ecs.NewSystemManager()
+	fsm.AddContext(network, physics)
+	engine := ecs.NewDefaultEngine(ecs.NewEntityManager(), ecs.NewSystemManager(),
+		ecs.WithClock(clock),
+		ecs.WithFixedTimestep(16*time.Millisecond, 3, fsm),
+		ecs.WithEssentialSystems(network))
+	engine.Tick()
+	engine.Pause()
+	clock.now = clock.now.Add(32 * time.Millisecond)
+	engine.Tick()
+	if len(network.deltaTime) != 2 || len(physics.deltaTime) != 0 {
+		t.Errorf("Paused Engine should process essential fixed systems only, but got %d and %d calls", len(network.deltaTime), len(physics.deltaTime))
+	}
+}
+
+func TestDefaultEngine_Tick_Should_Pause_If_System_Returns_StateEnginePause(t *testing.T) {
+	menu := &mockupStateSystem{states: []int{ecs.StateEnginePause}}
+	next := &mockupStateSystem{}
+	sm := ecs.NewSystemManager()
+	sm.AddContext(menu)
+	sm.Add(next)
+	engine := ecs.NewDefaultEngine(ecs.NewEntityManager(), sm, ecs.WithEssentialSystems(menu))
+	engine.Tick()
+	engine.Tick()
+	if !engine.Paused() {
+		t.Fatal("Engine should be paused")
+	}
+	if menu.processed != 2 || next.processed != 0 {
+		t.Errorf("Systems after the pause should not be processed, but got %d and %d calls", menu.processed, next.processed)
+	}
+	engine.Setup()
+	if engine.Paused() {
+		t.Error("Setup should resume the Engine")
+	}
+}
+
+func TestDefaultEngine_Tick_Should_Skip_Rest_If_System_Returns_StateEngineSkip(t *testing.T) {
+	em := ecs.NewEntityManager()
+	skip := &mockupStateSystem{states: []int{ecs.StateEngineSkip}}
+	next := &mockupStateSystem{}
+	sm := ecs.NewSystemManager()
+	sm.Add(skip, next)
+	engine := ecs.NewDefaultEngine(em, sm)
+	engine.Tick()
+	if next.processed != 0 || em.Tick() != 2 {
+		t.Errorf("Rest of the tick should be skipped, but got %d calls at tick %d", next.processed, em.Tick())
+	}
+	engine.Tick()
+	if skip.processed != 2 || next.processed != 1 {
+		t.Errorf("Next tick should process all the systems, but got %d and %d calls", skip.processed, next.processed)
+	}
+}
+
+func TestDefaultEngine_Tick_Should_Skip_Rest_After_Parallel_Stage(t *testing.T) {
+	skip := &mockupStateSystem{states: []int{ecs.StateEngineSkip}}
+	other := &mockupStateSystem{}
+	next := &mockupStateSystem{}
+	sm := ecs.NewSystemManager()
+	sm.Schedule(skip, ecs.Reads(1))
+	sm.Schedule(other, ecs.Reads(1))
+	sm.Schedule(next, ecs.Writes(1))
+	engine := ecs.NewDefaultEngine(ecs.NewEntityManager(), sm, ecs.WithParallelSystems())
+	engine.Tick()
+	if other.processed != 1 || next.processed != 0 {
+		t.Errorf("Rest of the tick should be skipped after the stage, but got %d and %d calls", other.processed, next.processed)
+	}
+}
+
+func TestDefaultEngine_Tick_Should_Restart_If_System_Returns_StateEngineRestart(t *testing.T) {
+	restart := &mockupStateSystem{states: []int{ecs.StateEngineRestart}}
+	next := &mockupStateSystem{}
+	sm := ecs.NewSystemManager()
+	sm.Add(restart, next)
+	engine := ecs.NewDefaultEngine(ecs.NewEntityManager(), sm, ecs.WithEssentialSystems(restart))
+	engine.Setup()
+	engine.Pause()
+	engine.Tick()
+	if next.processed != 0 {
+		t.Errorf("Rest of the tick should be skipped, but got %d calls", next.processed)
+	}
+	if engine.Paused() {
+		t.Error("Restart should resume the Engine")
+	}
+	if restart.setups != 2 || restart.teardowns != 1 || next.setups != 2 || next.teardowns != 1 {
+		t.Errorf("All the systems should be restarted, but got %d setups and %d teardowns", next.setups, next.teardowns)
+	}
+	engine.Tick()
+	if next.processed != 1 {
+		t.Errorf("Restarted systems should be processed, but got %d calls", next.processed)
+	}
+}
+
+func TestDefaultEngine_Pause_Should_Not_Process_Fixed_Systems(t *testing.T) {
+	clock := &mockupClock{now: time.Unix(0, 0)}
+	physics := &mockupStateSystem{}
+	fixed := ecs.NewSystemManager()
+	fixed.Add(physics)
+	engine := ecs.NewDefaultEngine(ecs.NewEntityManager(), ecs.NewSystemManager(),
+		ecs.WithClock(clock), ecs.WithFixedTimestep(time.Second, 5, fixed))
+	engine.Tick()
+	engine.Pause()
+	clock.now = clock.now.Add(3 * time.Second)
+	engine.Tick()
+	engine.Resume()
+	clock.now = clock.now.Add(time.Second)
+	engine.Tick()
+	if physics.processed != 1 {
+		t.Errorf("Time of the pause should not be caught up, but got %d steps", physics.processed)
+	}
+}
+
 /*
        _   _ _
  _   _| |_(_) |___
@@ -63,14 +338,46 @@ func (m *mockupEntityManager) Add(entities ...*ecs.Entity) {}
 
 func (m *mockupEntityManager) Entities() (entities []*ecs.Entity) { return nil }
 
+func (m *mockupEntityManager) Each(mask uint64) iter.Seq[*ecs.Entity] {
+	return func(yield func(*ecs.Entity) bool) {}
+}
+
+func (m *mockupEntityManager) EachBy(filter ecs.Filter) iter.Seq[*ecs.Entity] {
+	return func(yield func(*ecs.Entity) bool) {}
+}
+
 func (m *mockupEntityManager) FilterByMask(mask uint64) (entities []*ecs.Entity) { return nil }
 
+func (m *mockupEntityManager) FilterByBitset(mask ecs.Bitset) (entities []*ecs.Entity) { return nil }
+
+func (m *mockupEntityManager) FilterBy(filter ecs.Filter) (entities []*ecs.Entity) { return nil }
+
 func (m *mockupEntityManager) FilterByNames(names ...string) (entities []*ecs.Entity) { return nil }
 
-func (m *mockupEntityManager) Get(id uint32) (entity *ecs.Entity) { return nil }
+func (m *mockupEntityManager) NewEntity(components []ecs.Component) (entity *ecs.Entity) {
+	return nil
+}
+
+func (m *mockupEntityManager) Query(filter ecs.Filter) (query *ecs.Query) { return nil }
+
+func (m *mockupEntityManager) Observers() *ecs.Observers { return nil }
+
+func (m *mockupEntityManager) Resources() *ecs.Resources { return nil }
+
+func (m *mockupEntityManager) Get(id ecs.EntityID) (entity *ecs.Entity) { return nil }
+
+func (m *mockupEntityManager) IsAlive(id ecs.EntityID) bool { return false }
 
 func (m *mockupEntityManager) Remove(entity *ecs.Entity) {}
 
+func (m *mockupEntityManager) RemoveByID(id ecs.EntityID) {}
+
+func (m *mockupEntityManager) RemoveMany(entities ...*ecs.Entity) {}
+
+func (m *mockupEntityManager) RemoveByMask(mask uint64) {}
+
+func (m *mockupEntityManager) Reset() {}
+
 type mockupSystemManager struct {
 	systems []ecs.System
 }
@@ -79,10 +386,30 @@ func (m *mockupSystemManager) Add(systems ...ecs.System) {
 	m.systems = append(m.systems, systems...)
 }
 
+func (m *mockupSystemManager) AddContext(systems ...ecs.ContextSystem) {
+	for _, system := range systems {
+		m.systems = append(m.systems, ecs.AdaptContextSystem(system))
+	}
+}
+
+func (m *mockupSystemManager) Schedule(system ecs.System, opts ...ecs.SystemOption) {
+	m.systems = append(m.systems, system)
+}
+
+func (m *mockupSystemManager) Order() (names []string, err error) { return nil, nil }
+
 func (m *mockupSystemManager) Systems() []ecs.System {
 	return m.systems
 }
 
+func (m *mockupSystemManager) Stages() [][]ecs.System {
+	stages := make([][]ecs.System, len(m.systems))
+	for i, system := range m.systems {
+		stages[i] = []ecs.System{system}
+	}
+	return stages
+}
+
 type mockupSystem struct {
 	Counter int
 	State   int
@@ -94,3 +421,97 @@ func (s *mockupSystem) Process(entityManager ecs.EntityManager) (state int) {
 }
 func (s *mockupSystem) Setup()    {}
 func (s *mockupSystem) Teardown() {}
+
+// mockupParallelSystem waits for the other systems of the barrier and spawns an entity.
+type mockupParallelSystem struct {
+	barrier    *sync.WaitGroup
+	mask       uint64
+	concurrent bool
+}
+
+func (s *mockupParallelSystem) ProcessContext(ctx *ecs.Context) (state int) {
+	s.barrier.Done()
+	done := make(chan struct{})
+	go func() {
+		s.barrier.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		s.concurrent = true
+	case <-time.After(time.Second):
+	}
+	ctx.Commands.Spawn(&mockComponent{mask: s.mask})
+	return ecs.StateEngineContinue
+}
+func (s *mockupParallelSystem) Setup()    {}
+func (s *mockupParallelSystem) Teardown() {}
+
+// mockupClock returns a time, which is set by the test.
+type mockupClock struct {
+	now time.Time
+}
+
+func (c *mockupClock) Now() time.Time { return c.now }
+
+// mockupFrameSystem records the DeltaTime and Alpha of each call.
+type mockupFrameSystem struct {
+	deltaTime []time.Duration
+	alpha     []float64
+}
+
+func (s *mockupFrameSystem) ProcessContext(ctx *ecs.Context) (state int) {
+	s.deltaTime = append(s.deltaTime, ctx.DeltaTime)
+	s.alpha = append(s.alpha, ctx.Alpha)
+	return ecs.StateEngineContinue
+}
+func (s *mockupFrameSystem) Setup()    {}
+func (s *mockupFrameSystem) Teardown() {}
+
+// mockupCancelSystem cancels the context after a number of calls.
+type mockupCancelSystem struct {
+	cancel   func()
+	after    int
+	counter  int
+	err      error
+	teardown bool
+}
+
+func (s *mockupCancelSystem) ProcessContext(ctx *ecs.Context) (state int) {
+	s.counter++
+	if s.counter == s.after {
+		s.cancel()
+		s.err = ctx.Err()
+	}
+	return ecs.StateEngineContinue
+}
+func (s *mockupCancelSystem) Setup()    {}
+func (s *mockupCancelSystem) Teardown() { s.teardown = true }
+
+// mockupStateSystem returns the states one after another and continues afterwards.
+type mockupStateSystem struct {
+	states    []int
+	processed int
+	setups    int
+	teardowns int
+}
+
+func (s *mockupStateSystem) Process(entityManager ecs.EntityManager) (state int) {
+	s.processed++
+	if s.processed <= len(s.states) {
+		return s.states[s.processed-1]
+	}
+	return ecs.StateEngineContinue
+}
+
+func (s *mockupStateSystem) ProcessContext(ctx *ecs.Context) (state int) {
+	return s.Process(ctx.World)
+}
+
+func (s *mockupStateSystem) Setup() {
+	s.setups++
+}
+
+func (s *mockupStateSystem) Teardown() {
+	s.teardowns++
+}
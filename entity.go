@@ -1,31 +1,108 @@
 package ecs
 
-import (
-	"sync/atomic"
-
-	"github.com/bolom009/ecs/intmap"
-)
-
-var idCounter atomic.Uint64
+import "github.com/bolom009/ecs/intmap"
 
 // Entity is simply a composition of one or more Components with an Id.
+// The Id is assigned by the EntityManager, which the entity is added to.
 type Entity struct {
+	// Components stores the Components by their Mask().
 	Components *intmap.Map[uint64, Component]
-	Id         uint32 `json:"id"`
-	Masked     uint64 `json:"masked"`
+	Id         EntityID `json:"id"`
+	Masked     Bitset   `json:"masked"`
+	// wide stores the Components beyond the first 64 bits by their Bit().
+	wide *intmap.Map[uint, Component]
+	// named contains the interned names of the ComponentWithName while the entity is managed.
+	named Bitset
+	// ticks contains the added and changed ticks of the present Components sorted by their bit.
+	ticks []componentTicks
+	// observer is notified about mask changes while the entity is managed.
+	observer entityObserver
+}
+
+// entityObserver is notified by an Entity whenever its Components mask changes.
+type entityObserver interface {
+	maskChanged(entity *Entity, old Bitset)
+	// hooks returns the Observers called for the Components of the entity.
+	hooks() *Observers
+	// currentTick returns the stamp used to mark the Components as added or changed.
+	currentTick() uint64
+	// changedSince returns the first stamp matched by Added and Changed without Filter.Since.
+	changedSince() uint64
 }
 
-// Add a component.
+// adoptable reports whether the entity can be added to the observer.
+// It is false for an entity already added to the observer and panics for an entity of another one.
+func adoptable(entity *Entity, observer entityObserver) bool {
+	switch entity.observer {
+	case nil:
+		return true
+	case observer:
+		return false
+	}
+	panic("ecs: entity already belongs to another EntityManager")
+}
+
+// Add a component. Components already present are kept, use Set to replace them.
 func (e *Entity) Add(cn ...Component) {
+	old := e.Masked
+	tick := e.tick()
+	var buf [8]Component
+	added := buf[:0]
 	for _, c := range cn {
+		if bit, ok := wideBit(c); ok {
+			if e.Masked.Has(bit) {
+				continue
+			}
+
+			e.putWide(bit, c)
+			e.Masked = e.Masked.Set(bit)
+			e.stampBit(bit, tick, true)
+			added = append(added, c)
+			continue
+		}
+
 		cMask := c.Mask()
-		if e.Masked&cMask == cMask {
+		if e.Masked.lo&cMask == cMask {
 			continue
 		}
 
 		e.Components.Put(c.Mask(), c)
-		e.Masked = e.Masked | cMask
+		e.Masked.lo = e.Masked.lo | cMask
+		e.stampMask(cMask, tick, true)
+		added = append(added, c)
+	}
+
+	e.notify(old)
+	e.fire(hookAdd, added...)
+}
+
+// Set adds the components and replaces the ones already present, which are marked as changed.
+func (e *Entity) Set(cn ...Component) {
+	tick := e.tick()
+	var buf [8]Component
+	replaced, missing := buf[:0], make([]Component, 0)
+	for _, c := range cn {
+		if bit, ok := wideBit(c); ok {
+			if e.Masked.Has(bit) {
+				e.wide.Put(bit, c)
+				e.stampBit(bit, tick, false)
+				replaced = append(replaced, c)
+				continue
+			}
+		} else if _, ok := e.Components.Get(c.Mask()); ok {
+			e.Components.Put(c.Mask(), c)
+			e.stampMask(c.Mask(), tick, false)
+			replaced = append(replaced, c)
+			continue
+		}
+
+		missing = append(missing, c)
 	}
+
+	if len(missing) > 0 {
+		e.Add(missing...)
+	}
+	e.fire(hookSet, replaced...)
 }
 
 // Get a component by its bitmask.
@@ -38,8 +115,28 @@ func (e *Entity) Get(mask uint64) Component {
 	return nil
 }
 
-// Mask returns a pre-calculated maskSlice to identify the Components.
+// GetBit gets a component by its position in the Bitset.
+func (e *Entity) GetBit(bit uint) Component {
+	if bit < 64 {
+		return e.Get(1 << bit)
+	}
+
+	if e.wide != nil {
+		if c, ok := e.wide.Get(bit); ok {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// Mask returns the first 64 bits of the pre-calculated Bitset to identify the Components.
 func (e *Entity) Mask() uint64 {
+	return e.Masked.lo
+}
+
+// Bits returns the pre-calculated Bitset to identify the Components.
+func (e *Entity) Bits() Bitset {
 	return e.Masked
 }
 
@@ -47,40 +144,93 @@ func (e *Entity) Mask() uint64 {
 func (e *Entity) Remove(mask uint64) {
 	c, ok := e.Components.Get(mask)
 	if ok {
-		e.Masked = e.Masked &^ c.Mask()
+		old := e.Masked
+		e.Masked.lo = e.Masked.lo &^ c.Mask()
 		e.Components.Del(mask)
+		e.unstampMask(c.Mask())
+		e.notify(old)
+		e.fire(hookRemove, c)
+	}
+}
+
+// RemoveBit removes a component by using its position in the Bitset.
+func (e *Entity) RemoveBit(bit uint) {
+	if bit < 64 {
+		e.Remove(1 << bit)
+		return
+	}
+
+	if e.wide == nil {
+		return
+	}
+
+	if c, ok := e.wide.Get(bit); ok {
+		old := e.Masked
+		e.wide.Del(bit)
+		e.Masked = e.Masked.Unset(bit)
+		e.unstampBit(bit)
+		e.notify(old)
+		e.fire(hookRemove, c)
 	}
 }
 
+// notify informs the observer if the mask differs from the old one.
+func (e *Entity) notify(old Bitset) {
+	if e.observer != nil && !e.Masked.Equal(old) {
+		e.observer.maskChanged(e, old)
+	}
+}
+
+// fire calls the hooks of the observer for the components.
+func (e *Entity) fire(kind hookKind, cn ...Component) {
+	if e.observer == nil || len(cn) == 0 {
+		return
+	}
+
+	hooks := e.observer.hooks()
+	for _, c := range cn {
+		hooks.fire(kind, e, c)
+	}
+}
+
+// putWide stores a component beyond the first 64 bits.
+func (e *Entity) putWide(bit uint, c Component) {
+	if e.wide == nil {
+		e.wide = intmap.New[uint, Component](4)
+	}
+
+	e.wide.Put(bit, c)
+}
+
 // NewEntity creates a new entity and pre-calculates the component maskSlice.
+// The entity gets its Id by adding it to an EntityManager.
 func NewEntity(components []Component) *Entity {
-	eId := newId()
 	e := &Entity{
-		Components: intmap.New[uint64, Component](100),
-		Id:         eId,
+		Components: intmap.New[uint64, Component](len(components)),
 		Masked:     maskSlice(components),
 	}
 
 	for _, c := range components {
+		if bit, ok := wideBit(c); ok {
+			e.putWide(bit, c)
+			continue
+		}
+
 		e.Components.Put(c.Mask(), c)
 	}
 
 	return e
 }
 
-func newId() uint32 {
-	for {
-		val := idCounter.Load()
-		if idCounter.CompareAndSwap(val, val+1) {
-			return uint32(val)
+func maskSlice(components []Component) Bitset {
+	mask := Bitset{}
+	for _, c := range components {
+		if bit, ok := wideBit(c); ok {
+			mask = mask.Set(bit)
+			continue
 		}
-	}
-}
 
-func maskSlice(components []Component) uint64 {
-	mask := uint64(0)
-	for _, c := range components {
-		mask = mask | c.Mask()
+		mask.lo = mask.lo | c.Mask()
 	}
 	return mask
 }
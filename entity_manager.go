@@ -1,15 +1,77 @@
 package ecs
 
+import "iter"
+
 // EntityManager handles the access to each entity.
 type EntityManager interface {
-	// Add entries to the manager.
+	// Add entries to the manager and assign their Ids.
+	// Entities already added to this manager are ignored.
+	// It panics if an entity belongs to another EntityManager.
 	Add(entities ...*Entity)
 	// Entities returns all the entities.
 	Entities() (entities []*Entity)
+	// Each returns an iterator over the entities, which Components mask matched.
+	// Entities must not be added or removed while iterating.
+	// Iterating the managers of NewEntityManager and NewArchetypeEntityManager or a Query
+	// allocates nothing. Called by this interface, the iterator is allocated once per call.
+	Each(mask uint64) iter.Seq[*Entity]
+	// EachBy returns an iterator over the entities, which Components Bitset matches the Filter.
+	// Entities must not be added or removed while iterating.
+	EachBy(filter Filter) iter.Seq[*Entity]
 	// FilterByMask returns the mapped entities, which Components mask matched.
+	// The returned slice belongs to the caller, Each and Query iterate without copying the entities.
 	FilterByMask(mask uint64) (entities []*Entity)
+	// FilterByBitset returns the mapped entities, which Components Bitset contains the given one.
+	FilterByBitset(mask Bitset) (entities []*Entity)
+	// FilterBy returns the mapped entities, which Components Bitset matches the Filter.
+	FilterBy(filter Filter) (entities []*Entity)
+	// FilterByNames returns the mapped entities, which have a ComponentWithName for each name.
+	FilterByNames(names ...string) (entities []*Entity)
+	// NewEntity creates a new entity and adds it to the manager.
+	NewEntity(components []Component) (entity *Entity)
+	// Query registers the Filter and returns a Query, which matching entities are kept up to date.
+	Query(filter Filter) (query *Query)
+	// Observers returns the hooks, which are called when Components are added, removed or replaced
+	// and when entities are removed.
+	Observers() *Observers
+	// Resources returns the resources shared by the systems, use SetResource and Resource to access them.
+	// Reset keeps the resources.
+	Resources() *Resources
 	// Get a specific entity by Id.
-	Get(id uint32) (entity *Entity)
-	// Remove a specific entity.
+	Get(id EntityID) (entity *Entity)
+	// IsAlive reports whether the Id still belongs to an entity of the manager.
+	IsAlive(id EntityID) bool
+	// Remove a specific entity and release its Id for reuse.
+	// Entities, which do not belong to the manager, are ignored.
 	Remove(entity *Entity)
+	// RemoveByID removes the entity of the Id and releases the Id for reuse.
+	RemoveByID(id EntityID)
+	// RemoveMany removes the entities and releases their Ids for reuse.
+	RemoveMany(entities ...*Entity)
+	// RemoveByMask removes all the entities, which Components mask matched, in a single pass.
+	RemoveByMask(mask uint64)
+	// Reset removes all the entities and starts the Ids from the beginning.
+	// The OnRemove and OnDestroy hooks are called for each entity afterwards.
+	// The Ids of the removed entities are not alive afterwards.
+	Reset()
+}
+
+// ticker is implemented by the EntityManagers of this package. The Engine advances their world tick
+// and marks the runs of the systems, so that Added and Changed match the Components changed
+// since a System ran last.
+type ticker interface {
+	// Tick returns the current world tick, which starts at 1.
+	Tick() uint64
+	// AdvanceTick starts the next world tick.
+	AdvanceTick()
+	beginRun(last uint64) uint64
+	endRun()
+}
+
+// worldTick returns the current world tick of the EntityManager or 0, if it does not count them.
+func worldTick(entityManager EntityManager) uint64 {
+	if t, ok := entityManager.(ticker); ok {
+		return t.Tick()
+	}
+	return 0
 }
@@ -0,0 +1,421 @@
+package ecs
+
+import (
+	"iter"
+	"sync"
+
+	"github.com/bolom009/ecs/intmap"
+)
+
+// archetype is a table of all the entities sharing exactly the same Components mask.
+type archetype struct {
+	mask     Bitset
+	entities []*Entity
+}
+
+// archetypeFilter caches the archetypes, which match a Filter.
+type archetypeFilter struct {
+	filter     Filter
+	archetypes []*archetype
+}
+
+type archetypeEntityManager struct {
+	// mutex guards the cached filters, which are created by the readers,
+	// so that the systems of a parallel stage can filter the entities concurrently.
+	mutex       sync.Mutex
+	archetypes  []*archetype
+	byMask      *bitsetIndex[*archetype]
+	filters     []*archetypeFilter
+	byFilter    *bitsetIndex[*archetypeFilter]
+	byQuery     map[string]*archetypeFilter
+	mapEntities *intmap.Map[EntityID, *Entity]
+	rows        *intmap.Map[EntityID, int]
+	ids         *idAllocator
+	names       *nameIndex
+	queries     *queryRegistry
+	observers   *Observers
+	resources   *Resources
+	clock       *worldClock
+}
+
+// NewArchetypeEntityManager creates a new archetypeEntityManager and returns its address.
+// The entities are grouped by their Components mask, so FilterByMask only visits
+// the archetypes matching the requested mask instead of every single entity.
+func NewArchetypeEntityManager(cap ...int) *archetypeEntityManager {
+	vCap := 100
+	if len(cap) > 0 {
+		vCap = cap[0]
+	}
+
+	return &archetypeEntityManager{
+		archetypes:  make([]*archetype, 0),
+		byMask:      newBitsetIndex[*archetype](16),
+		filters:     make([]*archetypeFilter, 0),
+		byFilter:    newBitsetIndex[*archetypeFilter](16),
+		byQuery:     make(map[string]*archetypeFilter),
+		mapEntities: intmap.New[EntityID, *Entity](vCap),
+		rows:        intmap.New[EntityID, int](vCap),
+		ids:         newIdAllocator(vCap),
+		names:       newNameIndex(),
+		queries:     newQueryRegistry(),
+		observers:   newObservers(),
+		resources:   newResources(),
+		clock:       newWorldClock(),
+	}
+}
+
+// Add entries to the manager and assign their Ids.
+// Entities already added to this manager are ignored.
+// It panics if an entity belongs to another EntityManager.
+func (m *archetypeEntityManager) Add(entities ...*Entity) {
+	for _, entity := range entities {
+		if !adoptable(entity, m) {
+			continue
+		}
+		entity.Id = m.ids.next()
+		entity.stampAll(m.clock.stamp())
+		m.names.update(entity)
+		m.insert(entity)
+		m.mapEntities.Put(entity.Id, entity)
+		m.queries.added(entity)
+		entity.observer = m
+		m.observers.added(entity)
+	}
+}
+
+// Entities returns all the entities.
+func (m *archetypeEntityManager) Entities() []*Entity {
+	return m.FilterBy(Filter{})
+}
+
+// Each returns an iterator over the entities, which Components mask matched.
+func (m *archetypeEntityManager) Each(mask uint64) iter.Seq[*Entity] {
+	return func(yield func(*Entity) bool) {
+		for _, a := range m.filter(Filter{with: Bitset{lo: mask}}).archetypes {
+			for _, e := range a.entities {
+				if !yield(e) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// EachBy returns an iterator over the entities, which Components Bitset matches the Filter.
+func (m *archetypeEntityManager) EachBy(filter Filter) iter.Seq[*Entity] {
+	return m.each(m.filter(filter), filter)
+}
+
+// FilterByMask returns the mapped entities, which Components mask matched.
+func (m *archetypeEntityManager) FilterByMask(mask uint64) (entities []*Entity) {
+	return m.FilterByBitset(Bitset{lo: mask})
+}
+
+// FilterByBitset returns the mapped entities, which Components Bitset contains the given one.
+func (m *archetypeEntityManager) FilterByBitset(mask Bitset) (entities []*Entity) {
+	return m.FilterBy(Filter{with: mask})
+}
+
+// FilterBy returns the mapped entities, which Components Bitset matches the Filter.
+// The entities of the matching archetypes are copied into a new slice, which belongs to the caller.
+func (m *archetypeEntityManager) FilterBy(filter Filter) (entities []*Entity) {
+	f := m.filter(filter)
+	if filter.hasTicks() {
+		entities = make([]*Entity, 0)
+		for e := range m.each(f, filter) {
+			entities = append(entities, e)
+		}
+		return entities
+	}
+
+	return m.collect(f.archetypes)
+}
+
+// FilterByNames returns the mapped entities, which have a ComponentWithName for each name.
+func (m *archetypeEntityManager) FilterByNames(names ...string) (entities []*Entity) {
+	mask, ok := m.names.lookup(names)
+	if !ok {
+		return []*Entity{}
+	}
+
+	entities = make([]*Entity, 0)
+	for _, a := range m.archetypes {
+		for _, e := range a.entities {
+			if e.named.Contains(mask) {
+				entities = append(entities, e)
+			}
+		}
+	}
+	return entities
+}
+
+// NewEntity creates a new entity and adds it to the manager.
+func (m *archetypeEntityManager) NewEntity(components []Component) *Entity {
+	e := NewEntity(components)
+	m.Add(e)
+	return e
+}
+
+// Query registers the Filter and returns a Query, which matching entities are kept up to date.
+func (m *archetypeEntityManager) Query(filter Filter) *Query {
+	return m.queries.register(filter, m.Entities())
+}
+
+// Resources returns the resources shared by the systems, which are kept by Reset.
+func (m *archetypeEntityManager) Resources() *Resources {
+	return m.resources
+}
+
+// Tick returns the current world tick, which marks the Components as added or changed.
+// It is not part of EntityManager, the Engine advances it after each tick.
+func (m *archetypeEntityManager) Tick() uint64 {
+	return m.clock.tick
+}
+
+// AdvanceTick starts the next world tick.
+func (m *archetypeEntityManager) AdvanceTick() {
+	m.clock.advance()
+}
+
+// beginRun starts the run of a System, which ran last at the given stamp, and returns the stamp of this run.
+func (m *archetypeEntityManager) beginRun(last uint64) uint64 {
+	return m.clock.begin(last)
+}
+
+// endRun finishes the run of a System.
+func (m *archetypeEntityManager) endRun() {
+	m.clock.end()
+}
+
+// Get a specific entity by Id.
+func (m *archetypeEntityManager) Get(id EntityID) *Entity {
+	if v, ok := m.mapEntities.Get(id); ok {
+		return v
+	}
+
+	return nil
+}
+
+// IsAlive reports whether the Id still belongs to an entity of the manager.
+func (m *archetypeEntityManager) IsAlive(id EntityID) bool {
+	_, ok := m.mapEntities.Get(id)
+	return ok
+}
+
+// Remove a specific entity and release its Id for reuse.
+// Entities, which do not belong to the manager, are ignored.
+func (m *archetypeEntityManager) Remove(entity *Entity) {
+	if v, ok := m.mapEntities.Get(entity.Id); ok && v == entity {
+		m.RemoveByID(entity.Id)
+	}
+}
+
+// RemoveByID removes the entity of the Id and releases the Id for reuse.
+func (m *archetypeEntityManager) RemoveByID(id EntityID) {
+	e, ok := m.mapEntities.Get(id)
+	if !ok {
+		return
+	}
+
+	m.extract(e, e.Masked)
+	m.release(e)
+}
+
+// RemoveMany removes the entities and releases their Ids for reuse.
+func (m *archetypeEntityManager) RemoveMany(entities ...*Entity) {
+	for _, e := range entities {
+		m.Remove(e)
+	}
+}
+
+// RemoveByMask removes all the entities, which Components mask matched, and releases their Ids for reuse.
+// The matching archetypes are emptied as a whole instead of removing the entities one by one.
+func (m *archetypeEntityManager) RemoveByMask(mask uint64) {
+	for _, a := range m.filter(Filter{with: Bitset{lo: mask}}).archetypes {
+		for _, e := range a.entities {
+			m.rows.Del(e.Id)
+			m.release(e)
+		}
+		clear(a.entities)
+		a.entities = a.entities[:0]
+	}
+}
+
+// Reset removes all the entities and starts the Ids from the beginning.
+// The Ids of the removed entities are not alive afterwards.
+// The OnRemove and OnDestroy hooks are called for each entity afterwards.
+func (m *archetypeEntityManager) Reset() {
+	removed := m.collect(m.archetypes)
+	for _, a := range m.archetypes {
+		clear(a.entities)
+		a.entities = a.entities[:0]
+	}
+	m.mapEntities.Clear()
+	m.rows.Clear()
+	m.queries.reset()
+	m.ids.reset()
+	m.destroy(removed)
+}
+
+// destroy calls the removal hooks for the entities removed by Reset.
+func (m *archetypeEntityManager) destroy(entities []*Entity) {
+	for _, e := range entities {
+		e.observer = nil
+	}
+	for _, e := range entities {
+		m.observers.removed(e)
+	}
+}
+
+// release forgets the removed entity and releases its Id for reuse.
+func (m *archetypeEntityManager) release(e *Entity) {
+	m.mapEntities.Del(e.Id)
+	m.ids.release(e.Id)
+	m.queries.removed(e)
+	e.observer = nil
+	m.observers.removed(e)
+}
+
+// Observers returns the hooks called for the Components of the entities.
+func (m *archetypeEntityManager) Observers() *Observers {
+	return m.observers
+}
+
+// currentTick returns the current stamp of the manager.
+func (m *archetypeEntityManager) currentTick() uint64 {
+	return m.clock.stamp()
+}
+
+// changedSince returns the first stamp matched by Added and Changed without Filter.Since.
+func (m *archetypeEntityManager) changedSince() uint64 {
+	return m.clock.since()
+}
+
+// hooks returns the Observers of the manager.
+func (m *archetypeEntityManager) hooks() *Observers {
+	return m.observers
+}
+
+// maskChanged moves the entity into the archetype of its new mask and updates its names and Queries.
+func (m *archetypeEntityManager) maskChanged(entity *Entity, old Bitset) {
+	m.names.update(entity)
+	m.extract(entity, old)
+	m.insert(entity)
+	m.queries.changed(entity, old)
+}
+
+// archetype returns the archetype of the given mask and creates it if needed.
+func (m *archetypeEntityManager) archetype(mask Bitset) *archetype {
+	if a, ok := m.byMask.get(mask); ok {
+		return a
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	a := &archetype{mask: mask}
+	m.archetypes = append(m.archetypes, a)
+	m.byMask.put(mask, a)
+	// Keep the cached filters up to date.
+	for _, f := range m.filters {
+		if f.filter.Matches(mask) {
+			f.archetypes = append(f.archetypes, a)
+		}
+	}
+
+	return a
+}
+
+// filter returns the cached archetypes matching the Filter.
+func (m *archetypeEntityManager) filter(filter Filter) *archetypeFilter {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	withOnly := filter.isWithOnly()
+	key := ""
+	if withOnly {
+		if f, ok := m.byFilter.get(filter.with); ok {
+			return f
+		}
+	} else {
+		key = filter.key()
+		if f, ok := m.byQuery[key]; ok {
+			return f
+		}
+	}
+
+	f := &archetypeFilter{filter: filter}
+	for _, a := range m.archetypes {
+		if filter.Matches(a.mask) {
+			f.archetypes = append(f.archetypes, a)
+		}
+	}
+	m.filters = append(m.filters, f)
+	if withOnly {
+		m.byFilter.put(filter.with, f)
+	} else {
+		m.byQuery[key] = f
+	}
+
+	return f
+}
+
+// insert appends the entity to the archetype of its current mask.
+func (m *archetypeEntityManager) insert(entity *Entity) {
+	a := m.archetype(entity.Masked)
+	m.rows.Put(entity.Id, len(a.entities))
+	a.entities = append(a.entities, entity)
+}
+
+// extract removes the entity from the archetype of the given mask
+// by moving the last entity of the archetype into its row.
+func (m *archetypeEntityManager) extract(entity *Entity, mask Bitset) {
+	a, ok := m.byMask.get(mask)
+	if !ok {
+		return
+	}
+
+	row, ok := m.rows.Get(entity.Id)
+	if !ok {
+		return
+	}
+
+	last := len(a.entities) - 1
+	if row != last {
+		moved := a.entities[last]
+		a.entities[row] = moved
+		m.rows.Put(moved.Id, row)
+	}
+	a.entities[last] = nil
+	a.entities = a.entities[:last]
+	m.rows.Del(entity.Id)
+}
+
+// each returns an iterator over the entities of the cached archetypes,
+// which pass Added and Changed of the Filter.
+func (m *archetypeEntityManager) each(f *archetypeFilter, filter Filter) iter.Seq[*Entity] {
+	return func(yield func(*Entity) bool) {
+		for _, a := range f.archetypes {
+			for _, e := range a.entities {
+				if filter.matchesTicks(e) && !yield(e) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// collect copies the entities of all the archetypes into a new slice.
+func (m *archetypeEntityManager) collect(archetypes []*archetype) []*Entity {
+	count := 0
+	for _, a := range archetypes {
+		count += len(a.entities)
+	}
+
+	entities := make([]*Entity, 0, count)
+	for _, a := range archetypes {
+		entities = append(entities, a.entities...)
+	}
+
+	return entities
+}
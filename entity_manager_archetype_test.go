@@ -0,0 +1,266 @@
+package ecs_test
+
+import (
+	"testing"
+
+	"github.com/bolom009/ecs"
+)
+
+func TestArchetypeEntityManager_Entities_Should_Have_No_Entity_At_Start(t *testing.T) {
+	m := ecs.NewArchetypeEntityManager()
+	if len(m.Entities()) != 0 {
+		t.Errorf("EntityManager should have no entity at start, but got %d", len(m.Entities()))
+	}
+}
+
+func TestArchetypeEntityManager_Entities_Should_Have_Two_Entities_After_Adding_Two_Entities(t *testing.T) {
+	m := ecs.NewArchetypeEntityManager()
+	m.Add(ecs.NewEntity(nil))
+	m.Add(ecs.NewEntity([]ecs.Component{
+		&mockComponent{name: "position", mask: 1},
+	}))
+	if len(m.Entities()) != 2 {
+		t.Errorf("EntityManager should have two entities, but got %d", len(m.Entities()))
+	}
+}
+
+func TestArchetypeEntityManager_Entities_Should_Have_One_Entity_After_Removing_One_Of_Two_Entities(t *testing.T) {
+	m := ecs.NewArchetypeEntityManager()
+	e1 := ecs.NewEntity(nil)
+	e2 := ecs.NewEntity(nil)
+	m.Add(e1, e2)
+	m.Remove(e1)
+	if len(m.Entities()) != 1 {
+		t.Errorf("EntityManager should have one entity after removing one, but got %d", len(m.Entities()))
+	}
+	if m.Entities()[0].Id != e2.Id {
+		t.Errorf("Entity should have Id %d, but got %d", e2.Id, m.Entities()[0].Id)
+	}
+	if m.Get(e1.Id) != nil {
+		t.Error("Removed entity should be nil")
+	}
+}
+
+func TestArchetypeEntityManager_FilterByMask_Should_Return_Two_Entities_Out_Of_Three(t *testing.T) {
+	em := ecs.NewArchetypeEntityManager()
+	e1 := ecs.NewEntity([]ecs.Component{
+		&mockComponent{name: "position", mask: 1},
+	})
+	e2 := ecs.NewEntity([]ecs.Component{
+		&mockComponent{name: "position", mask: 1},
+		&mockComponent{name: "size", mask: 2},
+	})
+	e3 := ecs.NewEntity([]ecs.Component{
+		&mockComponent{name: "position", mask: 1},
+		&mockComponent{name: "size", mask: 2},
+		&mockComponent{name: "transform", mask: 4},
+	})
+	em.Add(e1, e2, e3)
+	filtered := em.FilterByMask(2)
+	if len(filtered) != 2 {
+		t.Errorf("EntityManager should return two entities, but got %d", len(filtered))
+	}
+	if filtered[0].Id != e2.Id {
+		t.Errorf("Entity should have Id %d, but got %d", e2.Id, filtered[0].Id)
+	}
+	if filtered[1].Id != e3.Id {
+		t.Errorf("Entity should have Id %d, but got %d", e3.Id, filtered[1].Id)
+	}
+}
+
+func TestArchetypeEntityManager_FilterByMask_Should_Follow_Entity_Add_And_Remove(t *testing.T) {
+	const (
+		MaskPosition = uint64(1 << iota)
+		MaskRotation
+		MaskSize
+	)
+
+	em := ecs.NewArchetypeEntityManager()
+	e1 := ecs.NewEntity([]ecs.Component{
+		&mockComponent{name: "position", mask: MaskPosition},
+		&mockComponent{name: "rotation", mask: MaskRotation},
+	})
+	e2 := ecs.NewEntity([]ecs.Component{
+		&mockComponent{name: "position", mask: MaskPosition},
+	})
+	em.Add(e1, e2)
+
+	if filtered := em.FilterByMask(MaskRotation); len(filtered) != 1 {
+		t.Errorf("EntityManager should return one entity, but got %d", len(filtered))
+	}
+
+	e1.Remove(MaskRotation)
+	if filtered := em.FilterByMask(MaskRotation); len(filtered) != 0 {
+		t.Errorf("EntityManager should return no entity, but got %d", len(filtered))
+	}
+
+	e2.Add(&mockComponent{name: "size", mask: MaskSize})
+	filtered := em.FilterByMask(MaskPosition | MaskSize)
+	if len(filtered) != 1 {
+		t.Fatalf("EntityManager should return one entity, but got %d", len(filtered))
+	}
+	if filtered[0] != e2 {
+		t.Errorf("Entity should have Id %d, but got %d", e2.Id, filtered[0].Id)
+	}
+	if filtered := em.FilterByMask(MaskPosition); len(filtered) != 2 {
+		t.Errorf("EntityManager should return two entities, but got %d", len(filtered))
+	}
+}
+
+func TestArchetypeEntityManager_FilterByMask_Should_Return_Independent_Entities(t *testing.T) {
+	em := ecs.NewArchetypeEntityManager()
+	em.Add(generateEntities(3)...)
+	filtered := em.FilterByMask(1)
+	filtered[0] = nil
+	if again := em.FilterByMask(1); len(again) != 3 || again[0] == nil {
+		t.Error("Modifying the returned entities should not change the next result")
+	}
+	em.Remove(em.FilterByMask(1)[1])
+	if len(filtered) != 3 || filtered[2] == nil {
+		t.Error("Previously returned entities should not be modified")
+	}
+	if again := em.FilterByMask(1); len(again) != 2 {
+		t.Errorf("EntityManager should return two entities, but got %d", len(again))
+	}
+}
+
+func TestArchetypeEntityManager_FilterByMask_Should_Be_Safe_For_Parallel_Systems(t *testing.T) {
+	em := ecs.NewArchetypeEntityManager()
+	em.Add(generateEntities(10)...)
+	r1 := &mockupFilterContextSystem{}
+	r2 := &mockupFilterContextSystem{}
+	sm := ecs.NewSystemManager()
+	sm.Schedule(ecs.AdaptContextSystem(r1), ecs.Reads(1))
+	sm.Schedule(ecs.AdaptContextSystem(r2), ecs.Reads(1))
+	sm.Schedule(ecs.AdaptContextSystem(&mockupFilterContextSystem{spawns: true}), ecs.Writes(1))
+	engine := ecs.NewDefaultEngine(em, sm, ecs.WithParallelSystems())
+	engine.Setup()
+	for range 10 {
+		engine.Tick()
+	}
+	if r1.found != 19 || r2.found != 19 {
+		t.Errorf("Systems should find the spawned entities, but got %d and %d", r1.found, r2.found)
+	}
+}
+
+func TestArchetypeEntityManager_Remove_Should_Keep_Other_Entities_Of_Archetype(t *testing.T) {
+	em := ecs.NewArchetypeEntityManager()
+	e1 := ecs.NewEntity([]ecs.Component{&mockComponent{name: "position", mask: 1}})
+	e2 := ecs.NewEntity([]ecs.Component{&mockComponent{name: "position", mask: 1}})
+	e3 := ecs.NewEntity([]ecs.Component{&mockComponent{name: "position", mask: 1}})
+	em.Add(e1, e2, e3)
+	em.Remove(e1)
+	em.Remove(e3)
+	filtered := em.FilterByMask(1)
+	if len(filtered) != 1 {
+		t.Fatalf("EntityManager should return one entity, but got %d", len(filtered))
+	}
+	if filtered[0] != e2 {
+		t.Errorf("Entity should have Id %d, but got %d", e2.Id, filtered[0].Id)
+	}
+	// A removed entity must not move the archetypes anymore.
+	e1.Remove(1)
+	if filtered := em.FilterByMask(1); len(filtered) != 1 {
+		t.Errorf("EntityManager should return one entity, but got %d", len(filtered))
+	}
+}
+
+func TestArchetypeEntityManager_Get_Should_Return_Entity(t *testing.T) {
+	em := ecs.NewArchetypeEntityManager()
+	e := ecs.NewEntity([]ecs.Component{&mockComponent{name: "position", mask: 1}})
+	em.Add(e)
+	if em.Get(e.Id) != e {
+		t.Error("Entity should be found")
+	}
+}
+
+func TestArchetypeEntityManager_FilterByBitset_Should_Support_More_Than_64_Components(t *testing.T) {
+	em := ecs.NewArchetypeEntityManager()
+	e1 := em.NewEntity([]ecs.Component{
+		&mockComponent{name: "position", mask: 1},
+		&mockWideComponent{bit: 100},
+	})
+	e2 := em.NewEntity([]ecs.Component{
+		&mockComponent{name: "position", mask: 1},
+	})
+	if filtered := em.FilterByBitset(ecs.NewBitset(0, 100)); len(filtered) != 1 || filtered[0] != e1 {
+		t.Errorf("EntityManager should return the entity %d, but got %v", e1.Id, filtered)
+	}
+	e2.Add(&mockWideComponent{bit: 100})
+	if filtered := em.FilterByBitset(ecs.NewBitset(100)); len(filtered) != 2 {
+		t.Errorf("EntityManager should return two entities, but got %d", len(filtered))
+	}
+	e1.RemoveBit(100)
+	if filtered := em.FilterByBitset(ecs.NewBitset(100)); len(filtered) != 1 || filtered[0] != e2 {
+		t.Errorf("EntityManager should return the entity %d, but got %v", e2.Id, filtered)
+	}
+	if filtered := em.FilterByMask(1); len(filtered) != 2 {
+		t.Errorf("EntityManager should return two entities, but got %d", len(filtered))
+	}
+}
+
+func TestArchetypeEntityManager_FilterByNames_Should_Follow_Entity_Add_And_Remove(t *testing.T) {
+	em := ecs.NewArchetypeEntityManager()
+	e1 := em.NewEntity([]ecs.Component{
+		&mockComponent{name: "position", mask: 1},
+		&mockComponent{name: "size", mask: 2},
+	})
+	e2 := em.NewEntity([]ecs.Component{
+		&mockComponent{name: "position", mask: 1},
+	})
+	if filtered := em.FilterByNames("position", "size"); len(filtered) != 1 || filtered[0] != e1 {
+		t.Errorf("EntityManager should return the entity %d, but got %v", e1.Id, filtered)
+	}
+	e1.Remove(2)
+	e2.Add(&mockComponent{name: "size", mask: 2})
+	if filtered := em.FilterByNames("size"); len(filtered) != 1 || filtered[0] != e2 {
+		t.Errorf("EntityManager should return the entity %d, but got %v", e2.Id, filtered)
+	}
+	if filtered := em.FilterByNames("unknown"); len(filtered) != 0 {
+		t.Errorf("EntityManager should return no entity, but got %d", len(filtered))
+	}
+}
+
+// mockupFilterContextSystem filters the entities by the masks of the tick or spawns an entity.
+type mockupFilterContextSystem struct {
+	spawns bool
+	found  int
+}
+
+func (s *mockupFilterContextSystem) ProcessContext(ctx *ecs.Context) (state int) {
+	if s.spawns {
+		ctx.Commands.Spawn(&mockComponent{name: "position", mask: 1})
+		return ecs.StateEngineContinue
+	}
+	s.found = len(ctx.World.FilterByMask(1))
+	ctx.World.FilterByMask(1 | ctx.Tick<<1)
+	return ecs.StateEngineContinue
+}
+func (s *mockupFilterContextSystem) Setup()    {}
+func (s *mockupFilterContextSystem) Teardown() {}
+
+func BenchmarkArchetypeEntityManager_FilterByMask(b *testing.B) {
+	em := ecs.NewArchetypeEntityManager()
+
+	entities := make([]*ecs.Entity, 500)
+	for i := range entities {
+		if i >= 0 && i <= 8 {
+			entities[i] = createBenchEntity(8)
+		} else if i > 8 && i <= 16 {
+			entities[i] = createBenchEntity(16)
+		} else if i > 16 && i <= 450 {
+			entities[i] = createBenchEntity(24)
+		} else if i > 450 {
+			entities[i] = createBenchEntity(30)
+		}
+	}
+
+	em.Add(entities...)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for b.Loop() {
+		em.FilterByMask(536870912)
+	}
+}
@@ -1,10 +1,20 @@
 package ecs
 
-import "github.com/bolom009/ecs/intmap"
+import (
+	"iter"
+
+	"github.com/bolom009/ecs/intmap"
+)
 
 type defaultEntityManager struct {
 	entities    []*Entity
-	mapEntities *intmap.Map[uint32, *Entity]
+	mapEntities *intmap.Map[EntityID, int]
+	ids         *idAllocator
+	names       *nameIndex
+	queries     *queryRegistry
+	observers   *Observers
+	resources   *Resources
+	clock       *worldClock
 }
 
 // NewEntityManager creates a new defaultEntityManager and returns its address.
@@ -16,15 +26,32 @@ func NewEntityManager(cap ...int) *defaultEntityManager {
 
 	return &defaultEntityManager{
 		entities:    make([]*Entity, 0),
-		mapEntities: intmap.New[uint32, *Entity](vCap),
+		mapEntities: intmap.New[EntityID, int](vCap),
+		ids:         newIdAllocator(vCap),
+		names:       newNameIndex(),
+		queries:     newQueryRegistry(),
+		observers:   newObservers(),
+		resources:   newResources(),
+		clock:       newWorldClock(),
 	}
 }
 
-// Add entries to the manager.
+// Add entries to the manager and assign their Ids.
+// Entities already added to this manager are ignored.
+// It panics if an entity belongs to another EntityManager.
 func (m *defaultEntityManager) Add(entities ...*Entity) {
-	m.entities = append(m.entities, entities...)
 	for _, entity := range entities {
-		m.mapEntities.Put(entity.Id, entity)
+		if !adoptable(entity, m) {
+			continue
+		}
+		entity.Id = m.ids.next()
+		entity.stampAll(m.clock.stamp())
+		entity.observer = m
+		m.names.update(entity)
+		m.mapEntities.Put(entity.Id, len(m.entities))
+		m.entities = append(m.entities, entity)
+		m.queries.added(entity)
+		m.observers.added(entity)
 	}
 }
 
@@ -33,6 +60,28 @@ func (m *defaultEntityManager) Entities() []*Entity {
 	return m.entities
 }
 
+// Each returns an iterator over the entities, which Components mask matched.
+func (m *defaultEntityManager) Each(mask uint64) iter.Seq[*Entity] {
+	return func(yield func(*Entity) bool) {
+		for _, e := range m.entities {
+			if e.Masked.lo&mask == mask && !yield(e) {
+				return
+			}
+		}
+	}
+}
+
+// EachBy returns an iterator over the entities, which Components Bitset matches the Filter.
+func (m *defaultEntityManager) EachBy(filter Filter) iter.Seq[*Entity] {
+	return func(yield func(*Entity) bool) {
+		for _, e := range m.entities {
+			if filter.matchesEntity(e) && !yield(e) {
+				return
+			}
+		}
+	}
+}
+
 // FilterByMask returns the mapped entities, which Components mask matched.
 func (m *defaultEntityManager) FilterByMask(mask uint64) (entities []*Entity) {
 	// Allocate the worst-case amount of memory (all entities needed).
@@ -52,24 +101,214 @@ func (m *defaultEntityManager) FilterByMask(mask uint64) (entities []*Entity) {
 	return entities[:index]
 }
 
+// FilterByBitset returns the mapped entities, which Components Bitset contains the given one.
+func (m *defaultEntityManager) FilterByBitset(mask Bitset) (entities []*Entity) {
+	if !mask.IsWide() {
+		return m.FilterByMask(mask.lo)
+	}
+
+	entities = make([]*Entity, len(m.entities))
+	index := 0
+	for _, e := range m.entities {
+		if e.Masked.Contains(mask) {
+			entities[index] = e
+			index++
+		}
+	}
+	return entities[:index]
+}
+
+// FilterBy returns the mapped entities, which Components Bitset matches the Filter.
+func (m *defaultEntityManager) FilterBy(filter Filter) (entities []*Entity) {
+	entities = make([]*Entity, len(m.entities))
+	index := 0
+	for _, e := range m.entities {
+		if filter.matchesEntity(e) {
+			entities[index] = e
+			index++
+		}
+	}
+	return entities[:index]
+}
+
+// FilterByNames returns the mapped entities, which have a ComponentWithName for each name.
+func (m *defaultEntityManager) FilterByNames(names ...string) (entities []*Entity) {
+	mask, ok := m.names.lookup(names)
+	if !ok {
+		return []*Entity{}
+	}
+
+	entities = make([]*Entity, len(m.entities))
+	index := 0
+	for _, e := range m.entities {
+		if e.named.Contains(mask) {
+			entities[index] = e
+			index++
+		}
+	}
+	return entities[:index]
+}
+
+// NewEntity creates a new entity and adds it to the manager.
+func (m *defaultEntityManager) NewEntity(components []Component) *Entity {
+	e := NewEntity(components)
+	m.Add(e)
+	return e
+}
+
+// Query registers the Filter and returns a Query, which matching entities are kept up to date.
+func (m *defaultEntityManager) Query(filter Filter) *Query {
+	return m.queries.register(filter, m.Entities())
+}
+
+// Resources returns the resources shared by the systems, which are kept by Reset.
+func (m *defaultEntityManager) Resources() *Resources {
+	return m.resources
+}
+
+// Tick returns the current world tick, which marks the Components as added or changed.
+// It is not part of EntityManager, the Engine advances it after each tick.
+func (m *defaultEntityManager) Tick() uint64 {
+	return m.clock.tick
+}
+
+// AdvanceTick starts the next world tick.
+func (m *defaultEntityManager) AdvanceTick() {
+	m.clock.advance()
+}
+
+// beginRun starts the run of a System, which ran last at the given stamp, and returns the stamp of this run.
+func (m *defaultEntityManager) beginRun(last uint64) uint64 {
+	return m.clock.begin(last)
+}
+
+// endRun finishes the run of a System.
+func (m *defaultEntityManager) endRun() {
+	m.clock.end()
+}
+
 // Get a specific entity by Id.
-func (m *defaultEntityManager) Get(id uint32) *Entity {
-	if v, ok := m.mapEntities.Get(id); ok {
-		return v
+func (m *defaultEntityManager) Get(id EntityID) *Entity {
+	if row, ok := m.mapEntities.Get(id); ok {
+		return m.entities[row]
 	}
 
 	return nil
 }
 
-// Remove a specific entity.
+// IsAlive reports whether the Id still belongs to an entity of the manager.
+func (m *defaultEntityManager) IsAlive(id EntityID) bool {
+	_, ok := m.mapEntities.Get(id)
+	return ok
+}
+
+// Remove a specific entity and release its Id for reuse.
+// The last entity is moved into the row of the removed one, so the order of the entities is not kept.
+// Entities, which do not belong to the manager, are ignored.
 func (m *defaultEntityManager) Remove(entity *Entity) {
-	for i, e := range m.entities {
-		if e.Id == entity.Id {
-			copy(m.entities[i:], m.entities[i+1:])
-			m.entities[len(m.entities)-1] = nil
-			m.entities = m.entities[:len(m.entities)-1]
-			m.mapEntities.Del(e.Id)
-			break
+	if row, ok := m.mapEntities.Get(entity.Id); ok && m.entities[row] == entity {
+		m.RemoveByID(entity.Id)
+	}
+}
+
+// RemoveByID removes the entity of the Id and releases the Id for reuse.
+func (m *defaultEntityManager) RemoveByID(id EntityID) {
+	row, ok := m.mapEntities.Get(id)
+	if !ok {
+		return
+	}
+
+	e := m.entities[row]
+	last := len(m.entities) - 1
+	if row != last {
+		moved := m.entities[last]
+		m.entities[row] = moved
+		m.mapEntities.Put(moved.Id, row)
+	}
+	m.entities[last] = nil
+	m.entities = m.entities[:last]
+	m.release(e)
+}
+
+// RemoveMany removes the entities and releases their Ids for reuse.
+func (m *defaultEntityManager) RemoveMany(entities ...*Entity) {
+	for _, e := range entities {
+		m.Remove(e)
+	}
+}
+
+// RemoveByMask removes all the entities, which Components mask matched, in a single pass
+// and releases their Ids for reuse. The order of the remaining entities is kept.
+func (m *defaultEntityManager) RemoveByMask(mask uint64) {
+	index := 0
+	for _, e := range m.entities {
+		if e.Masked.lo&mask == mask {
+			m.release(e)
+			continue
+		}
+		if m.entities[index] != e {
+			m.entities[index] = e
+			m.mapEntities.Put(e.Id, index)
 		}
+		index++
+	}
+	clear(m.entities[index:])
+	m.entities = m.entities[:index]
+}
+
+// Reset removes all the entities and starts the Ids from the beginning.
+// The Ids of the removed entities are not alive afterwards.
+// The OnRemove and OnDestroy hooks are called for each entity afterwards.
+func (m *defaultEntityManager) Reset() {
+	removed := m.entities
+	m.entities = make([]*Entity, 0, cap(removed))
+	m.mapEntities.Clear()
+	m.queries.reset()
+	m.ids.reset()
+	m.destroy(removed)
+}
+
+// destroy calls the removal hooks for the entities removed by Reset.
+func (m *defaultEntityManager) destroy(entities []*Entity) {
+	for _, e := range entities {
+		e.observer = nil
+	}
+	for _, e := range entities {
+		m.observers.removed(e)
 	}
 }
+
+// release forgets the removed entity and releases its Id for reuse.
+func (m *defaultEntityManager) release(e *Entity) {
+	m.mapEntities.Del(e.Id)
+	m.ids.release(e.Id)
+	m.queries.removed(e)
+	e.observer = nil
+	m.observers.removed(e)
+}
+
+// Observers returns the hooks called for the Components of the entities.
+func (m *defaultEntityManager) Observers() *Observers {
+	return m.observers
+}
+
+// currentTick returns the current stamp of the manager.
+func (m *defaultEntityManager) currentTick() uint64 {
+	return m.clock.stamp()
+}
+
+// changedSince returns the first stamp matched by Added and Changed without Filter.Since.
+func (m *defaultEntityManager) changedSince() uint64 {
+	return m.clock.since()
+}
+
+// hooks returns the Observers of the manager.
+func (m *defaultEntityManager) hooks() *Observers {
+	return m.observers
+}
+
+// maskChanged updates the names and the Queries of the entity.
+func (m *defaultEntityManager) maskChanged(entity *Entity, old Bitset) {
+	m.names.update(entity)
+	m.queries.changed(entity, old)
+}
@@ -41,7 +41,7 @@ func TestEntityManager_Entities_Should_Have_One_Entity_After_Removing_One_Of_Two
 	if len(m.Entities()) != 1 {
 		t.Errorf("EntityManager should have one entity after removing one, but got %d", len(m.Entities()))
 	}
-	if m.Entities()[0].Id != 2 {
+	if m.Entities()[0].Id != 1 {
 		t.Errorf("Entity should have correct Id, but got %d", m.Entities()[0].Id)
 	}
 }
@@ -84,7 +84,7 @@ func TestEntityManager_FilterByMask_Should_Return_One_Entity_Out_Of_Two(t *testi
 	if len(filtered) != 1 {
 		t.Errorf("EntityManager should return one entity, but got %d", len(filtered))
 	}
-	if filtered[0].Id != 7 {
+	if filtered[0].Id != e2.Id {
 		t.Errorf("Entity should have correct Id, but got %d", filtered[0].Id)
 	}
 }
@@ -107,10 +107,10 @@ func TestEntityManager_FilterByMask_Should_Return_Two_Entities_Out_Of_Three(t *t
 	if len(filtered) != 2 {
 		t.Errorf("EntityManager should return two entities, but got %d", len(filtered))
 	}
-	if filtered[0].Id != 9 {
+	if filtered[0].Id != e2.Id {
 		t.Errorf("Entity should have correct Id, but got %d", filtered[0].Id)
 	}
-	if filtered[1].Id != 10 {
+	if filtered[1].Id != e3.Id {
 		t.Errorf("Entity should have correct Id, but got %d", filtered[1].Id)
 	}
 }
@@ -135,13 +135,13 @@ func TestEntityManager_FilterByMask_Should_Return_Three_Entities_Out_Of_Three(t
 	if len(filtered) != 3 {
 		t.Errorf("EntityManager should return three entities, but got %d", len(filtered))
 	}
-	if filtered[0].Id != 11 {
+	if filtered[0].Id != e1.Id {
 		t.Errorf("Entity should have correct Id, but got %d", filtered[0].Id)
 	}
-	if filtered[1].Id != 12 {
+	if filtered[1].Id != e2.Id {
 		t.Errorf("Entity should have correct Id, but got %d", filtered[1].Id)
 	}
-	if filtered[2].Id != 13 {
+	if filtered[2].Id != e3.Id {
 		t.Errorf("Entity should have correct Id, but got %d", filtered[2].Id)
 	}
 }
@@ -210,6 +210,65 @@ func TestEntityManager_FilterByMask_Only_One_From_Three(t *testing.T) {
 	}
 }
 
+func TestEntityManager_FilterByBitset_Should_Support_More_Than_64_Components(t *testing.T) {
+	em := ecs.NewEntityManager()
+	e1 := em.NewEntity([]ecs.Component{
+		&mockComponent{name: "position", mask: 1},
+		&mockWideComponent{bit: 100},
+	})
+	em.NewEntity([]ecs.Component{
+		&mockComponent{name: "position", mask: 1},
+	})
+	if filtered := em.FilterByBitset(ecs.NewBitset(0, 100)); len(filtered) != 1 || filtered[0] != e1 {
+		t.Errorf("EntityManager should return the entity %d, but got %v", e1.Id, filtered)
+	}
+	if filtered := em.FilterByBitset(ecs.NewBitset(0)); len(filtered) != 2 {
+		t.Errorf("EntityManager should return two entities, but got %d", len(filtered))
+	}
+}
+
+func TestEntityManager_FilterByNames_Should_Return_Entities_With_All_Names(t *testing.T) {
+	em := ecs.NewEntityManager()
+	e1 := em.NewEntity([]ecs.Component{
+		&mockComponent{name: "position", mask: 1},
+		&mockComponent{name: "size", mask: 2},
+	})
+	e2 := em.NewEntity([]ecs.Component{
+		&mockComponent{name: "position", mask: 1},
+	})
+	if filtered := em.FilterByNames("position"); len(filtered) != 2 {
+		t.Errorf("EntityManager should return two entities, but got %d", len(filtered))
+	}
+	if filtered := em.FilterByNames("position", "size"); len(filtered) != 1 || filtered[0] != e1 {
+		t.Errorf("EntityManager should return the entity %d, but got %v", e1.Id, filtered)
+	}
+	if filtered := em.FilterByNames("position", "unknown"); len(filtered) != 0 {
+		t.Errorf("EntityManager should return no entity, but got %d", len(filtered))
+	}
+
+	e1.Remove(2)
+	e2.Add(&mockComponent{name: "size", mask: 2})
+	if filtered := em.FilterByNames("size"); len(filtered) != 1 || filtered[0] != e2 {
+		t.Errorf("EntityManager should return the entity %d, but got %v", e2.Id, filtered)
+	}
+}
+
+func TestEntityManager_FilterByNames_Should_Support_More_Than_64_Names(t *testing.T) {
+	em := ecs.NewEntityManager()
+	components := make([]ecs.Component, 100)
+	for i := range components {
+		components[i] = &mockWideComponent{bit: uint(64 + i), name: fmt.Sprintf("name_%d", i)}
+	}
+	em.NewEntity(components)
+	em.NewEntity(components[:80])
+	if filtered := em.FilterByNames("name_0", "name_99"); len(filtered) != 1 {
+		t.Errorf("EntityManager should return one entity, but got %d", len(filtered))
+	}
+	if filtered := em.FilterByNames("name_79"); len(filtered) != 2 {
+		t.Errorf("EntityManager should return two entities, but got %d", len(filtered))
+	}
+}
+
 func TestEntityManager_Get_Should_Return_Entity(t *testing.T) {
 	em := ecs.NewEntityManager()
 	e1 := ecs.NewEntity([]ecs.Component{
@@ -221,14 +280,134 @@ func TestEntityManager_Get_Should_Return_Entity(t *testing.T) {
 		&mockComponent{name: "size", mask: 2},
 	})
 	em.Add(e1, e2)
-	if e := em.Get(20); e == nil {
+	if e := em.Get(e1.Id); e == nil {
 		t.Error("Entity should not be nil")
 	}
-	if e := em.Get(21); e == nil {
+	if e := em.Get(e2.Id); e == nil {
 		t.Error("Entity should not be nil")
 	}
 }
 
+func TestEntityManager_Remove_Should_Keep_Get_Of_Moved_Entity(t *testing.T) {
+	em := ecs.NewEntityManager()
+	e1 := ecs.NewEntity(nil)
+	e2 := ecs.NewEntity(nil)
+	e3 := ecs.NewEntity(nil)
+	em.Add(e1, e2, e3)
+	em.Remove(e1)
+	if len(em.Entities()) != 2 {
+		t.Errorf("EntityManager should have two entities, but got %d", len(em.Entities()))
+	}
+	if e := em.Get(e3.Id); e != e3 {
+		t.Error("Moved entity should still be found by its Id")
+	}
+	if e := em.Get(e2.Id); e != e2 {
+		t.Error("Entity should still be found by its Id")
+	}
+	if em.IsAlive(e1.Id) {
+		t.Error("Removed entity should not be alive")
+	}
+}
+
+func TestEntityManager_RemoveByID_Should_Remove_Entity(t *testing.T) {
+	for name, em := range map[string]ecs.EntityManager{
+		"default":   ecs.NewEntityManager(),
+		"archetype": ecs.NewArchetypeEntityManager(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			e1 := em.NewEntity([]ecs.Component{&mockComponent{name: "position", mask: 1}})
+			e2 := em.NewEntity([]ecs.Component{&mockComponent{name: "position", mask: 1}})
+			q := em.Query(ecs.NewFilter().With(1))
+			em.RemoveByID(e1.Id)
+			em.RemoveByID(e1.Id)
+			if len(em.Entities()) != 1 || em.Entities()[0] != e2 {
+				t.Errorf("EntityManager should only have the second entity, but got %d entities", len(em.Entities()))
+			}
+			if em.IsAlive(e1.Id) {
+				t.Error("Removed entity should not be alive")
+			}
+			if q.Len() != 1 {
+				t.Errorf("Query should have one entity, but got %d", q.Len())
+			}
+		})
+	}
+}
+
+func TestEntityManager_RemoveMany_Should_Remove_All_Given_Entities(t *testing.T) {
+	for name, em := range map[string]ecs.EntityManager{
+		"default":   ecs.NewEntityManager(),
+		"archetype": ecs.NewArchetypeEntityManager(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			entities := make([]*ecs.Entity, 10)
+			for i := range entities {
+				entities[i] = em.NewEntity([]ecs.Component{&mockComponent{name: "position", mask: 1}})
+			}
+			em.RemoveMany(entities[0], entities[4], entities[9])
+			if len(em.Entities()) != 7 {
+				t.Errorf("EntityManager should have seven entities, but got %d", len(em.Entities()))
+			}
+			for i, e := range entities {
+				removed := i == 0 || i == 4 || i == 9
+				if em.IsAlive(e.Id) == removed {
+					t.Errorf("Entity %d should be alive: %v", i, !removed)
+				}
+				if !removed && em.Get(e.Id) != e {
+					t.Errorf("Entity %d should still be found by its Id", i)
+				}
+			}
+		})
+	}
+}
+
+func TestEntityManager_RemoveByMask_Should_Remove_Matching_Entities(t *testing.T) {
+	for name, em := range map[string]ecs.EntityManager{
+		"default":   ecs.NewEntityManager(),
+		"archetype": ecs.NewArchetypeEntityManager(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			e1 := em.NewEntity([]ecs.Component{&mockComponent{name: "position", mask: 1}})
+			e2 := em.NewEntity([]ecs.Component{
+				&mockComponent{name: "position", mask: 1},
+				&mockComponent{name: "bullet", mask: 2},
+			})
+			e3 := em.NewEntity([]ecs.Component{&mockComponent{name: "bullet", mask: 2}})
+			e4 := em.NewEntity([]ecs.Component{&mockComponent{name: "position", mask: 1}})
+			q := em.Query(ecs.NewFilter().With(1))
+			em.RemoveByMask(2)
+			if len(em.Entities()) != 2 {
+				t.Errorf("EntityManager should have two entities, but got %d", len(em.Entities()))
+			}
+			if em.IsAlive(e2.Id) || em.IsAlive(e3.Id) {
+				t.Error("Entities having the mask should be removed")
+			}
+			if em.Get(e1.Id) != e1 || em.Get(e4.Id) != e4 {
+				t.Error("Other entities should still be found by their Id")
+			}
+			if q.Len() != 2 {
+				t.Errorf("Query should have two entities, but got %d", q.Len())
+			}
+			if len(em.FilterByMask(2)) != 0 {
+				t.Error("FilterByMask should not return removed entities")
+			}
+		})
+	}
+}
+
+func BenchmarkEntityManager_RemoveByMask(b *testing.B) {
+	em := ecs.NewEntityManager()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for b.Loop() {
+		b.StopTimer()
+		em.Add(generateEntities(1000)...)
+		b.StartTimer()
+		em.RemoveByMask(2)
+	}
+}
+
 func BenchmarkEntityManager_FilterByMask(b *testing.B) {
 	em := ecs.NewEntityManager()
 
@@ -255,6 +434,20 @@ func BenchmarkEntityManager_FilterByMask(b *testing.B) {
 	}
 }
 
+func BenchmarkEntityManager_FilterByNames(b *testing.B) {
+	em := ecs.NewEntityManager()
+	for i := 0; i < 500; i++ {
+		em.Add(createBenchEntity(30))
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for b.Loop() {
+		em.FilterByNames("name_1", "name_29")
+	}
+}
+
 func createBenchEntity(lenComponents int) *ecs.Entity {
 	components := make([]ecs.Component, lenComponents)
 	for i := 0; i < lenComponents; i++ {
@@ -80,6 +80,30 @@ func TestEntity_Remove_Should_Work_With_Multiple_Components(t *testing.T) {
 	}
 }
 
+func TestEntity_Add_Should_Support_More_Than_64_Components(t *testing.T) {
+	entity := ecs.NewEntity([]ecs.Component{
+		&mockComponent{name: "position", mask: 1},
+		&mockWideComponent{bit: 64},
+	})
+	entity.Add(&mockWideComponent{bit: 130})
+	if !entity.Bits().Equal(ecs.NewBitset(0, 64, 130)) {
+		t.Error("Entity Bitset should contain the bits 0, 64 and 130")
+	}
+	if entity.Mask() != 1 {
+		t.Errorf("Entity mask should be 1, but got %d", entity.Mask())
+	}
+	if c := entity.GetBit(130); c == nil || c.(*mockWideComponent).bit != 130 {
+		t.Error("Component 130 should be found")
+	}
+	if entity.GetBit(0) == nil {
+		t.Error("Component 0 should be found")
+	}
+	entity.RemoveBit(64)
+	if entity.GetBit(64) != nil || entity.Bits().Has(64) {
+		t.Error("Component 64 should be removed")
+	}
+}
+
 /*
        _   _ _
  _   _| |_(_) |___
@@ -97,3 +121,14 @@ type mockComponent struct {
 func (c *mockComponent) Mask() uint64 { return c.mask }
 
 func (c *mockComponent) Name() string { return c.name }
+
+type mockWideComponent struct {
+	bit  uint
+	name string
+}
+
+func (c *mockWideComponent) Mask() uint64 { return 0 }
+
+func (c *mockWideComponent) Bit() uint { return c.bit }
+
+func (c *mockWideComponent) Name() string { return c.name }
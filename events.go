@@ -0,0 +1,132 @@
+package ecs
+
+import (
+	"iter"
+	"reflect"
+	"sync"
+)
+
+// Events is a double-buffered channel of events of type T.
+// Events sent during a tick can be read in the same and in the next tick,
+// afterwards they are cleared by the Engine.
+type Events[T any] struct {
+	previous []T
+	current  []T
+	// start is the sequence number of the first previous event.
+	start uint64
+}
+
+// Send an event, which can be read by the systems processed later in this tick
+// and by all the systems in the next tick.
+func (e *Events[T]) Send(event T) {
+	e.current = append(e.current, event)
+}
+
+// All returns an iterator over the events of the previous and the current tick.
+func (e *Events[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, event := range e.previous {
+			if !yield(event) {
+				return
+			}
+		}
+		for _, event := range e.current {
+			if !yield(event) {
+				return
+			}
+		}
+	}
+}
+
+// Len returns the number of events of the previous and the current tick.
+func (e *Events[T]) Len() int {
+	return len(e.previous) + len(e.current)
+}
+
+// Reader creates an EventReader, which reads each event only once.
+func (e *Events[T]) Reader() *EventReader[T] {
+	return &EventReader[T]{events: e}
+}
+
+// swap clears the events of the previous tick and keeps the events of the current tick for the next one.
+func (e *Events[T]) swap() {
+	e.start += uint64(len(e.previous))
+	clear(e.previous)
+	e.previous, e.current = e.current, e.previous[:0]
+}
+
+// EventReader reads the Events, which were not read by it before.
+// Each system should use its own EventReader.
+type EventReader[T any] struct {
+	events *Events[T]
+	// next is the sequence number of the next unread event.
+	next uint64
+}
+
+// Read returns an iterator over the unread events. Events cleared before they were read are skipped.
+func (r *EventReader[T]) Read() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		e := r.events
+		r.next = max(r.next, e.start)
+		for {
+			index := int(r.next - e.start)
+			var event T
+			switch {
+			case index < len(e.previous):
+				event = e.previous[index]
+			case index-len(e.previous) < len(e.current):
+				event = e.current[index-len(e.previous)]
+			default:
+				return
+			}
+			r.next++
+			if !yield(event) {
+				return
+			}
+		}
+	}
+}
+
+// eventChannel is implemented by Events of any type, so that the EventBus can swap them.
+type eventChannel interface {
+	swap()
+}
+
+// EventBus contains the Events of each type and is owned by the Engine,
+// which swaps the Events after each tick.
+type EventBus struct {
+	mutex    sync.Mutex
+	channels map[reflect.Type]eventChannel
+}
+
+// NewEventBus creates a new EventBus and returns its address.
+func NewEventBus() *EventBus {
+	return &EventBus{
+		channels: make(map[reflect.Type]eventChannel),
+	}
+}
+
+// EventsOf returns the Events of type T of the EventBus and creates them on first use.
+func EventsOf[T any](bus *EventBus) *Events[T] {
+	bus.mutex.Lock()
+	defer bus.mutex.Unlock()
+
+	t := reflect.TypeFor[T]()
+	if c, ok := bus.channels[t]; ok {
+		return c.(*Events[T])
+	}
+
+	events := &Events[T]{}
+	bus.channels[t] = events
+	return events
+}
+
+// swap swaps the Events of all the types.
+func (b *EventBus) swap() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for _, c := range b.channels {
+		c.swap()
+	}
+}
@@ -0,0 +1,118 @@
+package ecs_test
+
+import (
+	"testing"
+
+	"github.com/bolom009/ecs"
+)
+
+func TestEvents_Should_Be_Readable_In_Current_And_Next_Tick(t *testing.T) {
+	bus := ecs.NewEventBus()
+	engine := ecs.NewDefaultEngine(ecs.NewEntityManager(), ecs.NewSystemManager(), ecs.WithEventBus(bus))
+	events := ecs.EventsOf[collision](bus)
+	events.Send(collision{a: 1, b: 2})
+	if events.Len() != 1 {
+		t.Errorf("Events should have one event, but got %d", events.Len())
+	}
+	engine.Tick()
+	if events.Len() != 1 {
+		t.Errorf("Events should be kept for the next tick, but got %d", events.Len())
+	}
+	engine.Tick()
+	if events.Len() != 0 {
+		t.Errorf("Events should be cleared after two ticks, but got %d", events.Len())
+	}
+}
+
+func TestEventsOf_Should_Return_Same_Events_For_Same_Type(t *testing.T) {
+	bus := ecs.NewEventBus()
+	if ecs.EventsOf[collision](bus) != ecs.EventsOf[collision](bus) {
+		t.Error("EventsOf should return the same Events for the same type")
+	}
+	ecs.EventsOf[int](bus).Send(1)
+	if ecs.EventsOf[collision](bus).Len() != 0 {
+		t.Error("Events of different types should be separated")
+	}
+}
+
+func TestEventReader_Should_Read_Each_Event_Once(t *testing.T) {
+	bus := ecs.NewEventBus()
+	sender := &mockupSendSystem{events: ecs.EventsOf[collision](bus)}
+	reader := &mockupReadSystem{reader: ecs.EventsOf[collision](bus).Reader()}
+	sm := ecs.NewSystemManager()
+	// The reader is processed before the sender, so it reads the events in the next tick.
+	sm.Add(reader, sender)
+	engine := ecs.NewDefaultEngine(ecs.NewEntityManager(), sm, ecs.WithEventBus(bus))
+	engine.Tick()
+	if len(reader.read) != 0 {
+		t.Errorf("Reader should read no event in the first tick, but got %d", len(reader.read))
+	}
+	engine.Tick()
+	engine.Tick()
+	if len(reader.read) != 2 {
+		t.Fatalf("Reader should read each event once, but got %d", len(reader.read))
+	}
+	if reader.read[0].a != 1 || reader.read[1].a != 2 {
+		t.Errorf("Reader should read the events in order, but got %v", reader.read)
+	}
+}
+
+func BenchmarkEventReader_Read(b *testing.B) {
+	bus := ecs.NewEventBus()
+	events := ecs.EventsOf[collision](bus)
+	reader := events.Reader()
+	engine := ecs.NewDefaultEngine(ecs.NewEntityManager(), ecs.NewSystemManager(), ecs.WithEventBus(bus))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for b.Loop() {
+		for i := 0; i < 100; i++ {
+			events.Send(collision{a: i})
+		}
+		for range reader.Read() {
+		}
+		engine.Tick()
+	}
+}
+
+/*
+       _   _ _
+ _   _| |_(_) |___
+| | | | __| | / __|
+| |_| | |_| | \__ \
+ \__,_|\__|_|_|___/
+*/
+
+type collision struct {
+	a, b int
+}
+
+// mockupSendSystem sends a collision event with the number of its call.
+type mockupSendSystem struct {
+	events  *ecs.Events[collision]
+	counter int
+}
+
+func (s *mockupSendSystem) Process(em ecs.EntityManager) (state int) {
+	s.counter++
+	s.events.Send(collision{a: s.counter})
+	return ecs.StateEngineContinue
+}
+func (s *mockupSendSystem) Setup()    {}
+func (s *mockupSendSystem) Teardown() {}
+
+// mockupReadSystem collects the collision events.
+type mockupReadSystem struct {
+	reader *ecs.EventReader[collision]
+	read   []collision
+}
+
+func (s *mockupReadSystem) Process(em ecs.EntityManager) (state int) {
+	for event := range s.reader.Read() {
+		s.read = append(s.read, event)
+	}
+	return ecs.StateEngineContinue
+}
+func (s *mockupReadSystem) Setup()    {}
+func (s *mockupReadSystem) Teardown() {}
@@ -0,0 +1,138 @@
+package ecs
+
+// Filter describes the Components an entity must have (With), must not have (Without)
+// and of which it must have at least one (AnyOf).
+// Additionally it can require Components, which were added (Added) or changed (Changed)
+// since the running System ran last, during the current world tick outside of the systems
+// or since a given tick (Since).
+// A Filter is a value: all the methods return a new Filter instead of modifying it.
+type Filter struct {
+	with    Bitset
+	without Bitset
+	anyOf   Bitset
+	added   Bitset
+	changed Bitset
+	since   uint64
+}
+
+// NewFilter creates a new Filter, which matches all the entities.
+func NewFilter() Filter {
+	return Filter{}
+}
+
+// With requires all the Components of the mask.
+func (f Filter) With(mask uint64) Filter {
+	return f.WithBits(BitsetFromMask(mask))
+}
+
+// WithBits requires all the Components of the Bitset.
+func (f Filter) WithBits(mask Bitset) Filter {
+	f.with = f.with.Or(mask)
+	return f
+}
+
+// Without excludes the entities having any of the Components of the mask.
+func (f Filter) Without(mask uint64) Filter {
+	return f.WithoutBits(BitsetFromMask(mask))
+}
+
+// WithoutBits excludes the entities having any of the Components of the Bitset.
+func (f Filter) WithoutBits(mask Bitset) Filter {
+	f.without = f.without.Or(mask)
+	return f
+}
+
+// AnyOf requires at least one of the Components of the mask.
+// Calling AnyOf multiple times extends the same set of Components.
+func (f Filter) AnyOf(mask uint64) Filter {
+	return f.AnyOfBits(BitsetFromMask(mask))
+}
+
+// AnyOfBits requires at least one of the Components of the Bitset.
+// Calling AnyOfBits multiple times extends the same set of Components.
+func (f Filter) AnyOfBits(mask Bitset) Filter {
+	f.anyOf = f.anyOf.Or(mask)
+	return f
+}
+
+// Added requires all the Components of the mask, which must have been added
+// since the running System ran last or the tick given by Since.
+func (f Filter) Added(mask uint64) Filter {
+	return f.AddedBits(BitsetFromMask(mask))
+}
+
+// AddedBits requires all the Components of the Bitset, which must have been added
+// since the running System ran last or the tick given by Since.
+func (f Filter) AddedBits(mask Bitset) Filter {
+	f.added = f.added.Or(mask)
+	return f.WithBits(mask)
+}
+
+// Changed requires all the Components of the mask, which must have been added or changed
+// since the running System ran last or the tick given by Since.
+func (f Filter) Changed(mask uint64) Filter {
+	return f.ChangedBits(BitsetFromMask(mask))
+}
+
+// ChangedBits requires all the Components of the Bitset, which must have been added or changed
+// since the running System ran last or the tick given by Since.
+func (f Filter) ChangedBits(mask Bitset) Filter {
+	f.changed = f.changed.Or(mask)
+	return f.WithBits(mask)
+}
+
+// Since sets the world tick, at or after which the Components of Added and Changed
+// must have been added or changed. The world ticks start at 1.
+// Without Since a System processed by the Engine finds the changes since it ran last,
+// including those of the systems processed after it in the previous tick.
+func (f Filter) Since(tick uint64) Filter {
+	f.since = tick
+	return f
+}
+
+// Matches reports whether an entity with the given Components Bitset passes the Filter.
+func (f Filter) Matches(mask Bitset) bool {
+	return mask.Contains(f.with) &&
+		!mask.Intersects(f.without) &&
+		(f.anyOf.IsZero() || mask.Intersects(f.anyOf))
+}
+
+// matchesEntity reports whether the entity passes the Filter including Added and Changed.
+func (f Filter) matchesEntity(e *Entity) bool {
+	return f.Matches(e.Masked) && f.matchesTicks(e)
+}
+
+// matchesTicks reports whether the Components of Added and Changed were added or changed in time.
+// Matches does not check the ticks, so that the structural part of a Filter can be cached.
+func (f Filter) matchesTicks(e *Entity) bool {
+	if !f.hasTicks() {
+		return true
+	}
+
+	since := stampOf(f.since, 0)
+	if f.since == 0 {
+		since = e.changedSince()
+	}
+	return e.since(f.added, since, true) && e.since(f.changed, since, false)
+}
+
+// hasTicks reports whether the Filter checks the ticks of the Components.
+func (f Filter) hasTicks() bool {
+	return !f.added.IsZero() || !f.changed.IsZero()
+}
+
+// isWithOnly reports whether the Filter only requires Components.
+func (f Filter) isWithOnly() bool {
+	return f.without.IsZero() && f.anyOf.IsZero()
+}
+
+// key returns the Filter as a string to be used as a map key.
+func (f Filter) key() string {
+	buf := make([]byte, 0, 64)
+	for _, b := range []Bitset{f.with, f.without, f.anyOf} {
+		k := b.key()
+		buf = append(buf, byte(len(k)/8))
+		buf = append(buf, k...)
+	}
+	return string(buf)
+}
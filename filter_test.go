@@ -0,0 +1,98 @@
+package ecs_test
+
+import (
+	"testing"
+
+	"github.com/bolom009/ecs"
+)
+
+const (
+	maskPosition = uint64(1 << iota)
+	maskVelocity
+	maskDead
+	maskSprite
+	maskMesh
+)
+
+func TestFilter_Matches(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter ecs.Filter
+		mask   uint64
+		want   bool
+	}{
+		{"empty filter matches all", ecs.NewFilter(), maskDead, true},
+		{"with matches", ecs.NewFilter().With(maskPosition | maskVelocity), maskPosition | maskVelocity | maskDead, true},
+		{"with misses", ecs.NewFilter().With(maskPosition | maskVelocity), maskPosition, false},
+		{"without excludes", ecs.NewFilter().With(maskPosition).Without(maskDead), maskPosition | maskDead, false},
+		{"without passes", ecs.NewFilter().With(maskPosition).Without(maskDead), maskPosition, true},
+		{"any of first", ecs.NewFilter().AnyOf(maskSprite | maskMesh), maskSprite, true},
+		{"any of second", ecs.NewFilter().AnyOf(maskSprite).AnyOf(maskMesh), maskMesh, true},
+		{"any of none", ecs.NewFilter().AnyOf(maskSprite | maskMesh), maskPosition, false},
+		{"all combined", ecs.NewFilter().With(maskPosition).Without(maskDead).AnyOf(maskSprite | maskMesh), maskPosition | maskMesh, true},
+		{"all combined dead", ecs.NewFilter().With(maskPosition).Without(maskDead).AnyOf(maskSprite | maskMesh), maskPosition | maskMesh | maskDead, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(ecs.BitsetFromMask(tt.mask)); got != tt.want {
+				t.Errorf("Filter should return %v, but got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFilter_Matches_Should_Support_More_Than_64_Components(t *testing.T) {
+	f := ecs.NewFilter().WithBits(ecs.NewBitset(0, 100)).WithoutBits(ecs.NewBitset(200))
+	if !f.Matches(ecs.NewBitset(0, 100, 150)) {
+		t.Error("Filter should match")
+	}
+	if f.Matches(ecs.NewBitset(0, 100, 200)) {
+		t.Error("Filter should exclude bit 200")
+	}
+	if !ecs.NewFilter().AnyOfBits(ecs.NewBitset(1, 300)).Matches(ecs.NewBitset(300)) {
+		t.Error("Filter should match any of bit 300")
+	}
+}
+
+func TestEntityManager_FilterBy(t *testing.T) {
+	for name, em := range map[string]ecs.EntityManager{
+		"default":   ecs.NewEntityManager(),
+		"archetype": ecs.NewArchetypeEntityManager(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			alive := em.NewEntity([]ecs.Component{
+				&mockComponent{name: "position", mask: maskPosition},
+				&mockComponent{name: "velocity", mask: maskVelocity},
+				&mockComponent{name: "sprite", mask: maskSprite},
+			})
+			dead := em.NewEntity([]ecs.Component{
+				&mockComponent{name: "position", mask: maskPosition},
+				&mockComponent{name: "velocity", mask: maskVelocity},
+				&mockComponent{name: "dead", mask: maskDead},
+			})
+			mesh := em.NewEntity([]ecs.Component{
+				&mockComponent{name: "mesh", mask: maskMesh},
+			})
+
+			moving := ecs.NewFilter().With(maskPosition | maskVelocity).Without(maskDead)
+			if filtered := em.FilterBy(moving); len(filtered) != 1 || filtered[0] != alive {
+				t.Errorf("EntityManager should return the entity %d, but got %v", alive.Id, filtered)
+			}
+
+			visible := ecs.NewFilter().AnyOf(maskSprite | maskMesh)
+			if filtered := em.FilterBy(visible); len(filtered) != 2 {
+				t.Errorf("EntityManager should return two entities, but got %d", len(filtered))
+			}
+
+			dead.Remove(maskDead)
+			if filtered := em.FilterBy(moving); len(filtered) != 2 {
+				t.Errorf("EntityManager should return two entities, but got %d", len(filtered))
+			}
+
+			mesh.Add(&mockComponent{name: "dead", mask: maskDead})
+			if filtered := em.FilterBy(visible.Without(maskDead)); len(filtered) != 1 || filtered[0] != alive {
+				t.Errorf("EntityManager should return the entity %d, but got %v", alive.Id, filtered)
+			}
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package ecs
+
+import (
+	"fmt"
+	"iter"
+	"reflect"
+	"sync"
+)
+
+// componentKey identifies a Component type inside an Entity.
+type componentKey struct {
+	mask uint64
+	bit  uint
+	wide bool
+}
+
+// bits returns the Bitset of the Component type.
+func (k componentKey) bits() Bitset {
+	if k.wide {
+		return NewBitset(k.bit)
+	}
+
+	return Bitset{lo: k.mask}
+}
+
+// get returns the Component of the entity.
+func (k componentKey) get(e *Entity) Component {
+	if k.wide {
+		return e.GetBit(k.bit)
+	}
+
+	return e.Get(k.mask)
+}
+
+// componentKeys caches the componentKey of each Component type.
+var componentKeys sync.Map
+
+// keyOf returns the componentKey of T by calling Mask() and Bit() on a zero value of T once.
+// It panics if the zero value of T does not declare any bit, e.g. because Mask() depends on its fields.
+func keyOf[T Component]() componentKey {
+	t := reflect.TypeFor[T]()
+	if k, ok := componentKeys.Load(t); ok {
+		return k.(componentKey)
+	}
+
+	var zero T
+	if t.Kind() == reflect.Pointer {
+		zero = reflect.New(t.Elem()).Interface().(T)
+	}
+
+	if t.Kind() == reflect.Interface {
+		panic(fmt.Sprintf("ecs: component type %v must not be an interface", t))
+	}
+
+	k := componentKey{mask: zero.Mask()}
+	if bit, ok := wideBit(zero); ok {
+		k = componentKey{bit: bit, wide: true}
+	} else if k.mask == 0 {
+		panic(fmt.Sprintf("ecs: zero value of component type %v returns no Mask()", t))
+	}
+	componentKeys.Store(t, k)
+
+	return k
+}
+
+// BitOf returns the bit of the type T, which is assigned automatically on first use
+// by the process-global DefaultRegistry. It can be used to implement ComponentWithBit without
+// maintaining the bits by hand.
+func BitOf[T any]() uint {
+	return RegisterType[T](defaultRegistry)
+}
+
+// MaskOf returns the mask of the type T, which is assigned automatically on first use
+// by the process-global DefaultRegistry.
+// It can be used to implement Mask() without maintaining the 1<<n constants by hand:
+//
+//	func (p *Position) Mask() uint64 { return ecs.MaskOf[Position]() }
+//
+// It panics if more than 64 types are used. Use BitOf with ComponentWithBit in that case.
+func MaskOf[T any]() uint64 {
+	bit := BitOf[T]()
+	if bit >= 64 {
+		panic(fmt.Sprintf("ecs: MaskOf[%v] exceeds 64 bits, use BitOf instead", reflect.TypeFor[T]()))
+	}
+	return 1 << bit
+}
+
+// Get returns the Component of type T or its zero value, if the entity does not have it.
+// It looks up the bits of T on each call, use an Accessor or a typed Query in the hot path.
+func Get[T Component](e *Entity) T {
+	c, _ := keyOf[T]().get(e).(T)
+	return c
+}
+
+// Has reports whether the entity has a Component of type T.
+func Has[T Component](e *Entity) bool {
+	return e.Masked.Contains(keyOf[T]().bits())
+}
+
+// Accessor caches the bits of the Component type T, so that its Components are accessed
+// without looking up the type on each call, e.g. by keeping it in a System.
+type Accessor[T Component] struct {
+	key componentKey
+}
+
+// AccessorOf returns the Accessor of the Component type T.
+// It panics if the zero value of T does not declare any bit.
+func AccessorOf[T Component]() Accessor[T] {
+	return Accessor[T]{key: keyOf[T]()}
+}
+
+// Get returns the Component of type T or its zero value, if the entity does not have it.
+func (a Accessor[T]) Get(e *Entity) T {
+	c, _ := a.key.get(e).(T)
+	return c
+}
+
+// Has reports whether the entity has a Component of type T.
+func (a Accessor[T]) Has(e *Entity) bool {
+	return e.Masked.Contains(a.key.bits())
+}
+
+// Add a Component of type T to the entity.
+func Add[T Component](e *Entity, c T) {
+	e.Add(c)
+}
+
+// Set adds the Component of type T to the entity or replaces the present one.
+func Set[T Component](e *Entity, c T) {
+	e.Set(c)
+}
+
+// MarkChanged marks the Component of type T of the entity as changed in the current world tick.
+func MarkChanged[T Component](e *Entity) {
+	k := keyOf[T]()
+	if k.wide {
+		e.MarkChangedBit(k.bit)
+		return
+	}
+
+	e.MarkChanged(k.mask)
+}
+
+// Remove the Component of type T from the entity.
+func Remove[T Component](e *Entity) {
+	k := keyOf[T]()
+	if k.wide {
+		e.RemoveBit(k.bit)
+		return
+	}
+
+	e.Remove(k.mask)
+}
+
+// Query2 is a Query over the entities having the Components A and B,
+// which yields typed Components instead of entities.
+type Query2[A, B Component] struct {
+	query *Query
+	a, b  componentKey
+}
+
+// NewQuery2 registers a Query for the entities having the Components A and B.
+// An optional Filter narrows the Query further, e.g. by excluding Components.
+func NewQuery2[A, B Component](em EntityManager, filter ...Filter) *Query2[A, B] {
+	a, b := keyOf[A](), keyOf[B]()
+	return &Query2[A, B]{
+		query: em.Query(typedFilter(filter, a, b)),
+		a:     a,
+		b:     b,
+	}
+}
+
+// All returns an iterator over the Components of the matching entities.
+// Entities storing a Component of another type under the bits of A or B are skipped,
+// e.g. a *T for a Query over T.
+func (q *Query2[A, B]) All() iter.Seq2[A, B] {
+	return func(yield func(A, B) bool) {
+		for _, e := range q.query.entities {
+			if !q.query.filter.matchesTicks(e) {
+				continue
+			}
+			a, ok := q.a.get(e).(A)
+			if !ok {
+				continue
+			}
+			b, ok := q.b.get(e).(B)
+			if !ok {
+				continue
+			}
+			if !yield(a, b) {
+				return
+			}
+		}
+	}
+}
+
+// Query returns the underlying Query.
+func (q *Query2[A, B]) Query() *Query {
+	return q.query
+}
+
+// Row3 contains the Components of an entity matched by a Query3.
+type Row3[A, B, C Component] struct {
+	Entity *Entity
+	A      A
+	B      B
+	C      C
+}
+
+// Query3 is a Query over the entities having the Components A, B and C,
+// which yields typed Components instead of entities.
+type Query3[A, B, C Component] struct {
+	query   *Query
+	a, b, c componentKey
+}
+
+// NewQuery3 registers a Query for the entities having the Components A, B and C.
+// An optional Filter narrows the Query further, e.g. by excluding Components.
+func NewQuery3[A, B, C Component](em EntityManager, filter ...Filter) *Query3[A, B, C] {
+	a, b, c := keyOf[A](), keyOf[B](), keyOf[C]()
+	return &Query3[A, B, C]{
+		query: em.Query(typedFilter(filter, a, b, c)),
+		a:     a,
+		b:     b,
+		c:     c,
+	}
+}
+
+// All returns an iterator over the Components of the matching entities.
+// Entities storing a Component of another type under the bits of A, B or C are skipped.
+func (q *Query3[A, B, C]) All() iter.Seq[Row3[A, B, C]] {
+	return func(yield func(Row3[A, B, C]) bool) {
+		for _, e := range q.query.entities {
+			if !q.query.filter.matchesTicks(e) {
+				continue
+			}
+			var ok [3]bool
+			row := Row3[A, B, C]{Entity: e}
+			row.A, ok[0] = q.a.get(e).(A)
+			row.B, ok[1] = q.b.get(e).(B)
+			row.C, ok[2] = q.c.get(e).(C)
+			if ok != [3]bool{true, true, true} {
+				continue
+			}
+			if !yield(row) {
+				return
+			}
+		}
+	}
+}
+
+// Query returns the underlying Query.
+func (q *Query3[A, B, C]) Query() *Query {
+	return q.query
+}
+
+// typedFilter requires the Components of the keys in addition to the optional Filter.
+func typedFilter(filter []Filter, keys ...componentKey) Filter {
+	f := NewFilter()
+	if len(filter) > 0 {
+		f = filter[0]
+	}
+
+	for _, k := range keys {
+		f = f.WithBits(k.bits())
+	}
+	return f
+}
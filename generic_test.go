@@ -0,0 +1,213 @@
+package ecs_test
+
+import (
+	"testing"
+
+	"github.com/bolom009/ecs"
+)
+
+type health struct {
+	value int
+}
+
+func (h *health) Mask() uint64 { return ecs.MaskOf[health]() }
+
+type mana struct {
+	value int
+}
+
+func (m *mana) Mask() uint64 { return ecs.MaskOf[mana]() }
+
+type stamina struct {
+	value int
+}
+
+func (s *stamina) Mask() uint64 { return ecs.MaskOf[stamina]() }
+
+type dead struct{}
+
+func (d *dead) Mask() uint64 { return ecs.MaskOf[dead]() }
+
+// The Components of these tests use MaskOf instead of the fixed masks of position and velocity,
+// so they are never combined with those in the same entity.
+
+func TestMaskOf_Should_Assign_A_Stable_Bit_Per_Type(t *testing.T) {
+	if ecs.MaskOf[health]() == ecs.MaskOf[mana]() {
+		t.Error("Masks of different types should differ")
+	}
+	if ecs.MaskOf[health]() != ecs.MaskOf[health]() {
+		t.Error("Mask of the same type should be stable")
+	}
+	if ecs.MaskOf[health]() != 1<<ecs.BitOf[health]() {
+		t.Error("Mask should match the bit")
+	}
+}
+
+func TestGet_Should_Return_Typed_Component(t *testing.T) {
+	e := ecs.NewEntity([]ecs.Component{&mana{value: 1}, &health{value: 10}})
+	if m := ecs.Get[*mana](e); m == nil || m.value != 1 {
+		t.Errorf("Mana should be found, but got %v", m)
+	}
+	if h := ecs.Get[*health](e); h == nil || h.value != 10 {
+		t.Errorf("Health should be found, but got %v", h)
+	}
+	if s := ecs.Get[*stamina](e); s != nil {
+		t.Errorf("Stamina should be nil, but got %v", s)
+	}
+}
+
+func TestHas_Should_Panic_If_Zero_Value_Has_No_Mask(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("Has should panic for a type without a mask")
+		}
+	}()
+	ecs.Has[*zeroMask](ecs.NewEntity(nil))
+}
+
+func TestAccessor_Should_Return_Typed_Component(t *testing.T) {
+	e := ecs.NewEntity([]ecs.Component{&mana{value: 1}, &wideTag{}})
+	manaOf, tagOf := ecs.AccessorOf[*mana](), ecs.AccessorOf[*wideTag]()
+	if m := manaOf.Get(e); m == nil || m.value != 1 || !manaOf.Has(e) {
+		t.Errorf("Mana should be found, but got %v", m)
+	}
+	if tagOf.Get(e) == nil || !tagOf.Has(e) {
+		t.Error("Wide Component should be found")
+	}
+	if ecs.AccessorOf[*stamina]().Has(e) {
+		t.Error("Stamina should not be found")
+	}
+	if allocs := testing.AllocsPerRun(100, func() { manaOf.Get(e) }); allocs != 0 {
+		t.Errorf("Accessor should not allocate, but got %v allocations", allocs)
+	}
+}
+
+func TestHas_Add_Remove(t *testing.T) {
+	e := ecs.NewEntity(nil)
+	if ecs.Has[*mana](e) {
+		t.Error("Entity should not have mana")
+	}
+	ecs.Add(e, &mana{value: 3})
+	if !ecs.Has[*mana](e) || ecs.Get[*mana](e).value != 3 {
+		t.Error("Entity should have mana")
+	}
+	ecs.Remove[*mana](e)
+	if ecs.Has[*mana](e) {
+		t.Error("Entity should not have mana after removing it")
+	}
+}
+
+func TestGet_Should_Support_More_Than_64_Components(t *testing.T) {
+	e := ecs.NewEntity([]ecs.Component{&wideTag{}})
+	if !ecs.Has[*wideTag](e) || ecs.Get[*wideTag](e) == nil {
+		t.Error("Entity should have the wide Component")
+	}
+	ecs.Remove[*wideTag](e)
+	if ecs.Has[*wideTag](e) {
+		t.Error("Entity should not have the wide Component after removing it")
+	}
+}
+
+func TestQuery2_Should_Yield_Typed_Components(t *testing.T) {
+	em := ecs.NewArchetypeEntityManager()
+	em.Add(generateEntities(2)...)
+	em.NewEntity([]ecs.Component{&position{}})
+	q := ecs.NewQuery2[*position, *velocity](em)
+
+	count := 0
+	for pos, vel := range q.All() {
+		pos.x += vel.x
+		count++
+	}
+	if count != 2 {
+		t.Errorf("Query2 should yield two pairs, but got %d", count)
+	}
+	if q.Query().Len() != 2 {
+		t.Errorf("Query should contain two entities, but got %d", q.Query().Len())
+	}
+}
+
+func TestQuery3_Should_Respect_Additional_Filter(t *testing.T) {
+	em := ecs.NewEntityManager()
+	alive := em.NewEntity([]ecs.Component{&health{value: 3}, &mana{value: 2}, &stamina{value: 1}})
+	em.NewEntity([]ecs.Component{&health{}, &mana{}, &stamina{}, &dead{}})
+	q := ecs.NewQuery3[*health, *mana, *stamina](em, ecs.NewFilter().Without(ecs.MaskOf[dead]()))
+
+	count := 0
+	for row := range q.All() {
+		if row.Entity != alive {
+			t.Errorf("Query3 should only yield the entity %d, but got %d", alive.Id, row.Entity.Id)
+		}
+		row.A.value += row.B.value * row.C.value
+		count++
+	}
+	if count != 1 {
+		t.Errorf("Query3 should yield one row, but got %d", count)
+	}
+	if h := ecs.Get[*health](alive).value; h != 5 {
+		t.Errorf("Health should be 5, but got %d", h)
+	}
+}
+
+func TestQuery2_All_Should_Skip_Components_Of_Other_Type(t *testing.T) {
+	em := ecs.NewEntityManager()
+	em.NewEntity([]ecs.Component{&valuePosition{}, &valueVelocity{}})
+	em.NewEntity([]ecs.Component{valuePosition{x: 1}, valueVelocity{x: 2}})
+	count := 0
+	for pos, vel := range ecs.NewQuery2[valuePosition, valueVelocity](em).All() {
+		if pos.x != 1 || vel.x != 2 {
+			t.Errorf("Query2 should yield the stored values, but got %v and %v", pos, vel)
+		}
+		count++
+	}
+	rows := 0
+	for range ecs.NewQuery3[valuePosition, valueVelocity, *valuePosition](em).All() {
+		rows++
+	}
+	if count != 1 || rows != 0 {
+		t.Errorf("Queries should skip the pointers, but got %d and %d", count, rows)
+	}
+}
+
+// valuePosition and valueVelocity declare their masks by value receivers,
+// so they can be stored as values or as pointers.
+type valuePosition struct {
+	x float64
+}
+
+func (p valuePosition) Mask() uint64 { return ecs.MaskOf[valuePosition]() }
+
+type valueVelocity struct {
+	x float64
+}
+
+func (v valueVelocity) Mask() uint64 { return ecs.MaskOf[valueVelocity]() }
+
+// zeroMask declares its mask by a field, so its zero value has none.
+type zeroMask struct {
+	mask uint64
+}
+
+func (z *zeroMask) Mask() uint64 { return z.mask }
+
+type wideTag struct{}
+
+func (w *wideTag) Mask() uint64 { return 0 }
+
+func (w *wideTag) Bit() uint { return 64 + ecs.BitOf[wideTag]() }
+
+func BenchmarkQuery2_All(b *testing.B) {
+	em := ecs.NewArchetypeEntityManager(100000)
+	em.Add(generateEntities(100000)...)
+	q := ecs.NewQuery2[*position, *velocity](em)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for b.Loop() {
+		for pos, vel := range q.All() {
+			pos.x += vel.x * 0.33
+			pos.y += vel.y * 0.33
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package ecs
+
+// EntityID identifies an Entity by an index and a generation.
+// The index is recycled after the Entity has been removed, but its generation is
+// increased at the same time, so that a stale EntityID never matches a new Entity.
+type EntityID uint64
+
+// Index returns the slot of the EntityID, which is reused after a removal.
+func (id EntityID) Index() uint32 {
+	return uint32(id)
+}
+
+// Generation returns how many times the index of the EntityID has been recycled.
+func (id EntityID) Generation() uint32 {
+	return uint32(id >> 32)
+}
+
+func newEntityID(index, generation uint32) EntityID {
+	return EntityID(uint64(generation)<<32 | uint64(index))
+}
+
+// idAllocator hands out the EntityIDs of a single EntityManager and recycles the indices of released ones.
+// The index 0 is never handed out, so the zero EntityID marks an entity, which was not added yet.
+type idAllocator struct {
+	generations []uint32
+	free        []uint32
+}
+
+func newIdAllocator(cap int) *idAllocator {
+	return &idAllocator{
+		generations: make([]uint32, 1, cap+1),
+		free:        make([]uint32, 0),
+	}
+}
+
+// next returns a recycled index with its current generation or a new index.
+func (a *idAllocator) next() EntityID {
+	if n := len(a.free); n > 0 {
+		index := a.free[n-1]
+		a.free = a.free[:n-1]
+		return newEntityID(index, a.generations[index])
+	}
+
+	index := uint32(len(a.generations))
+	a.generations = append(a.generations, 0)
+	return newEntityID(index, 0)
+}
+
+// release invalidates the EntityID and puts its index onto the free list.
+func (a *idAllocator) release(id EntityID) {
+	index := id.Index()
+	if index == 0 || int(index) >= len(a.generations) || a.generations[index] != id.Generation() {
+		return
+	}
+
+	a.generations[index]++
+	a.free = append(a.free, index)
+}
+
+// reset invalidates all the handed out EntityIDs and starts again at the first index.
+// The generations are increased, so that an EntityID handed out before never matches a new Entity.
+func (a *idAllocator) reset() {
+	a.free = a.free[:0]
+	for index := len(a.generations) - 1; index > 0; index-- {
+		a.generations[index]++
+		a.free = append(a.free, uint32(index))
+	}
+}
@@ -0,0 +1,138 @@
+package ecs_test
+
+import (
+	"testing"
+
+	"github.com/bolom009/ecs"
+)
+
+func TestEntityID_Remove_Should_Recycle_Index_With_Next_Generation(t *testing.T) {
+	m := ecs.NewEntityManager()
+	e1 := m.NewEntity(nil)
+	m.Remove(e1)
+	e2 := m.NewEntity(nil)
+	if e2.Id.Index() != e1.Id.Index() {
+		t.Errorf("Index should be %d, but got %d", e1.Id.Index(), e2.Id.Index())
+	}
+	if e2.Id.Generation() != e1.Id.Generation()+1 {
+		t.Errorf("Generation should be %d, but got %d", e1.Id.Generation()+1, e2.Id.Generation())
+	}
+	if e2.Id == e1.Id {
+		t.Error("Recycled Id should differ from the removed one")
+	}
+}
+
+func TestEntityManager_IsAlive_Should_Detect_Stale_Id(t *testing.T) {
+	for name, m := range map[string]ecs.EntityManager{
+		"default":   ecs.NewEntityManager(),
+		"archetype": ecs.NewArchetypeEntityManager(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			e1 := m.NewEntity(nil)
+			if !m.IsAlive(e1.Id) {
+				t.Error("Entity should be alive after adding")
+			}
+			stale := e1.Id
+			m.Remove(e1)
+			e2 := m.NewEntity(nil)
+			if m.IsAlive(stale) {
+				t.Error("Removed entity should not be alive")
+			}
+			if m.Get(stale) != nil {
+				t.Error("Stale Id should not return the recycled entity")
+			}
+			if !m.IsAlive(e2.Id) {
+				t.Error("Recycled entity should be alive")
+			}
+		})
+	}
+}
+
+func TestEntityID_Should_Be_Allocated_Per_EntityManager(t *testing.T) {
+	m1 := ecs.NewEntityManager()
+	m2 := ecs.NewArchetypeEntityManager()
+	for i := 1; i <= 3; i++ {
+		e1 := m1.NewEntity(nil)
+		e2 := m2.NewEntity(nil)
+		if e1.Id != ecs.EntityID(i) || e2.Id != ecs.EntityID(i) {
+			t.Errorf("Ids should be %d, but got %d and %d", i, e1.Id, e2.Id)
+		}
+	}
+}
+
+func TestEntityManager_Reset_Should_Start_Ids_From_The_Beginning(t *testing.T) {
+	for name, m := range map[string]ecs.EntityManager{
+		"default":   ecs.NewEntityManager(),
+		"archetype": ecs.NewArchetypeEntityManager(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			stale := m.NewEntity(nil).Id
+			m.NewEntity([]ecs.Component{&mockComponent{name: "position", mask: 1}})
+			m.Reset()
+			if len(m.Entities()) != 0 {
+				t.Errorf("EntityManager should have no entity after reset, but got %d", len(m.Entities()))
+			}
+			if len(m.FilterByMask(1)) != 0 {
+				t.Errorf("EntityManager should filter no entity after reset, but got %d", len(m.FilterByMask(1)))
+			}
+			e := m.NewEntity(nil)
+			if e.Id.Index() != 1 {
+				t.Errorf("Index should be 1 after reset, but got %d", e.Id.Index())
+			}
+			if m.IsAlive(stale) || m.Get(stale) != nil {
+				t.Error("Id from before the reset should not be alive")
+			}
+		})
+	}
+}
+
+func TestEntityManager_Add_Should_Ignore_Entity_Added_Twice(t *testing.T) {
+	for name, m := range map[string]ecs.EntityManager{
+		"default":   ecs.NewEntityManager(),
+		"archetype": ecs.NewArchetypeEntityManager(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			e := m.NewEntity(nil)
+			id := e.Id
+			m.Add(e)
+			if len(m.Entities()) != 1 || e.Id != id {
+				t.Fatalf("Entity should be added once, but got %d entities", len(m.Entities()))
+			}
+			m.Remove(e)
+			if m.IsAlive(id) || m.Get(id) != nil {
+				t.Error("Removed entity should not be alive")
+			}
+		})
+	}
+}
+
+func TestEntityManager_Add_Should_Panic_For_Entity_Of_Another_Manager(t *testing.T) {
+	a, b := ecs.NewEntityManager(), ecs.NewArchetypeEntityManager()
+	e := a.NewEntity(nil)
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("Add should panic for an entity of another EntityManager")
+		}
+	}()
+	b.Add(e)
+}
+
+func TestEntityManager_Remove_Should_Ignore_Entity_Of_Another_Manager(t *testing.T) {
+	for name, m := range map[string]ecs.EntityManager{
+		"default":   ecs.NewEntityManager(),
+		"archetype": ecs.NewArchetypeEntityManager(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			other := ecs.NewEntityManager()
+			e := m.NewEntity(nil)
+			foreign := other.NewEntity(nil)
+			if foreign.Id != e.Id {
+				t.Fatal("Ids should be allocated per EntityManager")
+			}
+			m.Remove(foreign)
+			if !m.IsAlive(e.Id) || len(m.Entities()) != 1 {
+				t.Error("Remove should not remove the entity with the same Id")
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package ecs
+
+import "iter"
+
+// EachComponent returns an iterator over the entities of the sequence together with their
+// Component of the mask converted to A. Each entity of the sequence must have the Component.
+func EachComponent[A Component](entities iter.Seq[*Entity], mask uint64) iter.Seq2[*Entity, A] {
+	return func(yield func(*Entity, A) bool) {
+		for e := range entities {
+			if !yield(e, e.Get(mask).(A)) {
+				return
+			}
+		}
+	}
+}
+
+// EachComponent2 returns an iterator over the Components of the masks converted to A and B
+// for each entity of the sequence. Each entity of the sequence must have both Components.
+func EachComponent2[A, B Component](entities iter.Seq[*Entity], maskA, maskB uint64) iter.Seq2[A, B] {
+	return func(yield func(A, B) bool) {
+		for e := range entities {
+			if !yield(e.Get(maskA).(A), e.Get(maskB).(B)) {
+				return
+			}
+		}
+	}
+}
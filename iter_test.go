@@ -0,0 +1,141 @@
+package ecs_test
+
+import (
+	"testing"
+
+	"github.com/bolom009/ecs"
+)
+
+func TestEntityManager_Each(t *testing.T) {
+	for name, em := range map[string]ecs.EntityManager{
+		"default":   ecs.NewEntityManager(),
+		"archetype": ecs.NewArchetypeEntityManager(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			em.Add(generateEntities(3)...)
+			em.NewEntity([]ecs.Component{&position{}})
+
+			count := 0
+			for e := range em.Each(1 | 2) {
+				if e.Mask() != 3 {
+					t.Errorf("Entity mask should be 3, but got %d", e.Mask())
+				}
+				count++
+			}
+			if count != 3 {
+				t.Errorf("Each should yield three entities, but got %d", count)
+			}
+
+			count = 0
+			for range em.EachBy(ecs.NewFilter().With(1).Without(2)) {
+				count++
+			}
+			if count != 1 {
+				t.Errorf("EachBy should yield one entity, but got %d", count)
+			}
+
+			count = 0
+			for range em.Each(1) {
+				count++
+				break
+			}
+			if count != 1 {
+				t.Errorf("Each should stop after break, but got %d", count)
+			}
+		})
+	}
+}
+
+func TestEntityManager_Each_Should_Not_Allocate(t *testing.T) {
+	em := ecs.NewEntityManager()
+	em.Add(generateEntities(100)...)
+	am := ecs.NewArchetypeEntityManager()
+	am.Add(generateEntities(100)...)
+	q := am.Query(ecs.NewFilter().With(1 | 2))
+	count := 0
+	for name, fn := range map[string]func(){
+		"default": func() {
+			for e := range em.Each(1 | 2) {
+				count += int(e.Mask())
+			}
+		},
+		"archetype": func() {
+			for e := range am.Each(1 | 2) {
+				count += int(e.Mask())
+			}
+		},
+		"query": func() {
+			for e := range q.All() {
+				count += int(e.Mask())
+			}
+		},
+	} {
+		if allocs := testing.AllocsPerRun(100, fn); allocs != 0 {
+			t.Errorf("Iterating by %s should not allocate, but got %v allocations", name, allocs)
+		}
+	}
+}
+
+func TestEntityManager_Each_Should_Allocate_Independent_Of_Entities(t *testing.T) {
+	for name, em := range map[string]ecs.EntityManager{
+		"default":   ecs.NewEntityManager(),
+		"archetype": ecs.NewArchetypeEntityManager(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			count := 0
+			each := func() {
+				for e := range em.Each(1 | 2) {
+					count += int(e.Mask())
+				}
+			}
+			em.Add(generateEntities(1)...)
+			few := testing.AllocsPerRun(100, each)
+			em.Add(generateEntities(1000)...)
+			if many := testing.AllocsPerRun(100, each); many != few {
+				t.Errorf("Allocations should not depend on the entities, but got %v and %v", few, many)
+			}
+		})
+	}
+}
+
+func TestEachComponent_Should_Yield_Typed_Components(t *testing.T) {
+	em := ecs.NewArchetypeEntityManager()
+	em.Add(generateEntities(2)...)
+
+	for e, pos := range ecs.EachComponent[*position](em.Each(1), 1) {
+		if e.Get(1) != pos {
+			t.Error("Component should belong to the entity")
+		}
+		pos.x = 5
+	}
+
+	count := 0
+	for pos, vel := range ecs.EachComponent2[*position, *velocity](em.Query(ecs.NewFilter().With(1|2)).All(), 1, 2) {
+		pos.x += vel.x
+		count++
+	}
+	if count != 2 {
+		t.Errorf("EachComponent2 should yield two pairs, but got %d", count)
+	}
+	for _, e := range em.Entities() {
+		if x := e.Get(1).(*position).x; x != 6 {
+			t.Errorf("Position should be 6, but got %v", x)
+		}
+	}
+}
+
+func BenchmarkEachComponent2_With_Query(b *testing.B) {
+	em := ecs.NewArchetypeEntityManager(100000)
+	em.Add(generateEntities(100000)...)
+	q := em.Query(ecs.NewFilter().With(1 | 2))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for b.Loop() {
+		for pos, vel := range ecs.EachComponent2[*position, *velocity](q.All(), 1, 2) {
+			pos.x += vel.x * 0.33
+			pos.y += vel.y * 0.33
+		}
+	}
+}
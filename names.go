@@ -0,0 +1,54 @@
+package ecs
+
+// nameIndex interns the names of the ComponentWithName to the slots of a Bitset,
+// so that FilterByNames compares bits instead of strings for each entity.
+type nameIndex struct {
+	slots map[string]uint
+}
+
+func newNameIndex() *nameIndex {
+	return &nameIndex{
+		slots: make(map[string]uint),
+	}
+}
+
+// intern returns the slot of the name and assigns the next free slot to an unknown name.
+func (n *nameIndex) intern(name string) uint {
+	slot, ok := n.slots[name]
+	if !ok {
+		slot = uint(len(n.slots))
+		n.slots[name] = slot
+	}
+	return slot
+}
+
+// lookup returns the Bitset of the names or false if at least one name is unknown.
+func (n *nameIndex) lookup(names []string) (Bitset, bool) {
+	mask := Bitset{}
+	for _, name := range names {
+		slot, ok := n.slots[name]
+		if !ok {
+			return mask, false
+		}
+		mask = mask.Set(slot)
+	}
+	return mask, true
+}
+
+// update pre-calculates the Bitset of the component names of the entity.
+func (n *nameIndex) update(e *Entity) {
+	named := Bitset{}
+	add := func(_ uint64, c Component) {
+		if cn, ok := c.(ComponentWithName); ok {
+			named = named.Set(n.intern(cn.Name()))
+		}
+	}
+
+	if e.Components != nil {
+		e.Components.ForEach(add)
+	}
+	if e.wide != nil {
+		e.wide.ForEach(func(_ uint, c Component) { add(0, c) })
+	}
+	e.named = named
+}
@@ -0,0 +1,138 @@
+package ecs
+
+// Hook is called with the entity and the Component, which was added, removed or replaced.
+type Hook func(entity *Entity, component Component)
+
+// hookKind is the lifecycle event of a Component.
+type hookKind int
+
+const (
+	hookAdd hookKind = iota
+	hookRemove
+	hookSet
+	hookKinds
+)
+
+// destroyHook is called for the removed entities, which Components mask matched.
+type destroyHook struct {
+	mask uint64
+	hook func(entity *Entity)
+}
+
+// Observers contains the hooks of an EntityManager, which are called when Components
+// are added to, removed from or replaced in its entities and when its entities are removed.
+// The hooks are called after the change was applied and the Queries were updated.
+// They must not add or remove entities directly, use a CommandBuffer instead.
+// Reset calls the OnRemove and OnDestroy hooks of all the entities like Remove.
+type Observers struct {
+	hooks   [hookKinds]map[componentKey][]Hook
+	destroy []destroyHook
+}
+
+func newObservers() *Observers {
+	o := &Observers{
+		destroy: make([]destroyHook, 0),
+	}
+	for kind := range o.hooks {
+		o.hooks[kind] = make(map[componentKey][]Hook)
+	}
+	return o
+}
+
+// OnAdd registers a hook, which is called when a Component of the mask is added to an entity
+// or when an entity having it is added to the EntityManager.
+func (o *Observers) OnAdd(mask uint64, hook Hook) {
+	o.on(hookAdd, componentKey{mask: mask}, hook)
+}
+
+// OnRemove registers a hook, which is called when a Component of the mask is removed from an entity
+// or when an entity having it is removed from the EntityManager.
+func (o *Observers) OnRemove(mask uint64, hook Hook) {
+	o.on(hookRemove, componentKey{mask: mask}, hook)
+}
+
+// OnSet registers a hook, which is called when a Component of the mask is replaced by Entity.Set.
+func (o *Observers) OnSet(mask uint64, hook Hook) {
+	o.on(hookSet, componentKey{mask: mask}, hook)
+}
+
+// OnDestroy registers a hook, which is called when an entity, which Components mask matched,
+// is removed from the EntityManager. It is called after the OnRemove hooks of its Components.
+func (o *Observers) OnDestroy(mask uint64, hook func(entity *Entity)) {
+	o.destroy = append(o.destroy, destroyHook{mask: mask, hook: hook})
+}
+
+// on registers the hook for the Component of the key.
+func (o *Observers) on(kind hookKind, key componentKey, hook Hook) {
+	o.hooks[kind][key] = append(o.hooks[kind][key], hook)
+}
+
+// fire calls the hooks of the Component.
+func (o *Observers) fire(kind hookKind, e *Entity, c Component) {
+	hooks := o.hooks[kind]
+	if len(hooks) == 0 {
+		return
+	}
+
+	key := componentKey{mask: c.Mask()}
+	if bit, ok := wideBit(c); ok {
+		key = componentKey{bit: bit, wide: true}
+	}
+	for _, hook := range hooks[key] {
+		hook(e, c)
+	}
+}
+
+// added calls the OnAdd hooks for all the Components of an entity added to the EntityManager.
+func (o *Observers) added(e *Entity) {
+	o.fireAll(hookAdd, e)
+}
+
+// removed calls the OnRemove hooks for all the Components of an entity removed
+// from the EntityManager and its OnDestroy hooks.
+func (o *Observers) removed(e *Entity) {
+	o.fireAll(hookRemove, e)
+	for _, d := range o.destroy {
+		if e.Masked.lo&d.mask == d.mask {
+			d.hook(e)
+		}
+	}
+}
+
+// fireAll calls the hooks for all the Components of the entity.
+func (o *Observers) fireAll(kind hookKind, e *Entity) {
+	if len(o.hooks[kind]) == 0 {
+		return
+	}
+
+	if e.Components != nil {
+		e.Components.ForEach(func(_ uint64, c Component) { o.fire(kind, e, c) })
+	}
+	if e.wide != nil {
+		e.wide.ForEach(func(_ uint, c Component) { o.fire(kind, e, c) })
+	}
+}
+
+// OnAdd registers a hook at the Observers, which is called when a Component of type T is added.
+func OnAdd[T Component](o *Observers, hook func(entity *Entity, component T)) {
+	o.on(hookAdd, keyOf[T](), typedHook(hook))
+}
+
+// OnRemove registers a hook at the Observers, which is called when a Component of type T is removed.
+func OnRemove[T Component](o *Observers, hook func(entity *Entity, component T)) {
+	o.on(hookRemove, keyOf[T](), typedHook(hook))
+}
+
+// OnSet registers a hook at the Observers, which is called when a Component of type T is replaced.
+func OnSet[T Component](o *Observers, hook func(entity *Entity, component T)) {
+	o.on(hookSet, keyOf[T](), typedHook(hook))
+}
+
+// typedHook converts a hook of a Component type into a Hook.
+func typedHook[T Component](hook func(entity *Entity, component T)) Hook {
+	return func(entity *Entity, component Component) {
+		if c, ok := component.(T); ok {
+			hook(entity, c)
+		}
+	}
+}
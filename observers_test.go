@@ -0,0 +1,148 @@
+package ecs_test
+
+import (
+	"testing"
+
+	"github.com/bolom009/ecs"
+)
+
+func TestObservers_Should_Call_Hooks_On_Add_Set_And_Remove(t *testing.T) {
+	for name, em := range map[string]ecs.EntityManager{
+		"default":   ecs.NewEntityManager(),
+		"archetype": ecs.NewArchetypeEntityManager(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			events := make([]string, 0)
+			record := func(event string) ecs.Hook {
+				return func(e *ecs.Entity, c ecs.Component) {
+					events = append(events, event+":"+c.(*mockComponent).name)
+				}
+			}
+			em.Observers().OnAdd(2, record("add"))
+			em.Observers().OnSet(2, record("set"))
+			em.Observers().OnRemove(2, record("remove"))
+
+			e := em.NewEntity([]ecs.Component{&mockComponent{name: "position", mask: 1}})
+			e.Add(&mockComponent{name: "collider", mask: 2})
+			e.Add(&mockComponent{name: "ignored", mask: 2})
+			e.Set(&mockComponent{name: "replaced", mask: 2})
+			e.Remove(2)
+			e.Remove(2)
+
+			expected := []string{"add:collider", "set:replaced", "remove:replaced"}
+			if len(events) != len(expected) {
+				t.Fatalf("Hooks should be called %v, but got %v", expected, events)
+			}
+			for i := range expected {
+				if events[i] != expected[i] {
+					t.Errorf("Hook %d should be %s, but got %s", i, expected[i], events[i])
+				}
+			}
+		})
+	}
+}
+
+func TestObservers_Should_Call_Hooks_On_Manager_Add_And_Remove(t *testing.T) {
+	for name, em := range map[string]ecs.EntityManager{
+		"default":   ecs.NewEntityManager(),
+		"archetype": ecs.NewArchetypeEntityManager(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			added, removed, destroyed := 0, 0, 0
+			em.Observers().OnAdd(1, func(e *ecs.Entity, c ecs.Component) { added++ })
+			em.Observers().OnRemove(1, func(e *ecs.Entity, c ecs.Component) { removed++ })
+			em.Observers().OnDestroy(1, func(e *ecs.Entity) {
+				if removed != destroyed+1 {
+					t.Error("OnDestroy should be called after OnRemove")
+				}
+				destroyed++
+			})
+
+			e1 := em.NewEntity([]ecs.Component{&mockComponent{name: "position", mask: 1}})
+			e2 := em.NewEntity([]ecs.Component{&mockComponent{name: "velocity", mask: 2}})
+			if added != 1 {
+				t.Errorf("OnAdd should be called once, but got %d", added)
+			}
+			em.Remove(e1)
+			em.Remove(e2)
+			if removed != 1 || destroyed != 1 {
+				t.Errorf("OnRemove and OnDestroy should be called once, but got %d and %d", removed, destroyed)
+			}
+		})
+	}
+}
+
+func TestObservers_Should_Call_Hooks_On_Reset(t *testing.T) {
+	for name, em := range map[string]ecs.EntityManager{
+		"default":   ecs.NewEntityManager(),
+		"archetype": ecs.NewArchetypeEntityManager(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			bodies := make(map[*ecs.Entity]bool)
+			em.Observers().OnAdd(2, func(e *ecs.Entity, c ecs.Component) { bodies[e] = true })
+			em.Observers().OnRemove(2, func(e *ecs.Entity, c ecs.Component) {
+				if em.IsAlive(e.Id) {
+					t.Error("OnRemove should be called after the entity was removed")
+				}
+				delete(bodies, e)
+			})
+			destroyed := 0
+			em.Observers().OnDestroy(1, func(e *ecs.Entity) { destroyed++ })
+			em.Add(generateEntities(3)...)
+			em.NewEntity([]ecs.Component{&mockComponent{name: "position", mask: 1}})
+			em.Reset()
+			if len(bodies) != 0 || destroyed != 4 {
+				t.Errorf("Reset should call the hooks for each entity, but got %d bodies and %d calls", len(bodies), destroyed)
+			}
+		})
+	}
+}
+
+func TestObservers_Should_Not_Call_Hooks_Of_Unmanaged_Entity(t *testing.T) {
+	em := ecs.NewEntityManager()
+	called := false
+	em.Observers().OnAdd(1, func(e *ecs.Entity, c ecs.Component) { called = true })
+	e := ecs.NewEntity(nil)
+	e.Add(&mockComponent{name: "position", mask: 1})
+	if called {
+		t.Error("Hook should not be called for an entity without EntityManager")
+	}
+}
+
+func TestObservers_Typed_Hooks_Should_Receive_Component(t *testing.T) {
+	em := ecs.NewArchetypeEntityManager()
+	var got *health
+	tagged := 0
+	ecs.OnSet(em.Observers(), func(e *ecs.Entity, h *health) { got = h })
+	ecs.OnAdd(em.Observers(), func(e *ecs.Entity, w *wideTag) { tagged++ })
+	ecs.OnRemove(em.Observers(), func(e *ecs.Entity, w *wideTag) { tagged-- })
+
+	e := em.NewEntity([]ecs.Component{&health{value: 10}})
+	ecs.Set(e, &health{value: 5})
+	if got == nil || got.value != 5 {
+		t.Error("OnSet should receive the new Component")
+	}
+	if ecs.Get[*health](e).value != 5 {
+		t.Errorf("Component should be replaced, but got %d", ecs.Get[*health](e).value)
+	}
+	ecs.Add(e, &wideTag{})
+	if tagged != 1 {
+		t.Errorf("OnAdd should be called for a wide Component, but got %d", tagged)
+	}
+	ecs.Remove[*wideTag](e)
+	if tagged != 0 {
+		t.Errorf("OnRemove should be called for a wide Component, but got %d", tagged)
+	}
+}
+
+func TestCommandBuffer_Flush_Should_Call_Hooks(t *testing.T) {
+	em := ecs.NewEntityManager()
+	destroyed := 0
+	em.Observers().OnDestroy(0, func(e *ecs.Entity) { destroyed++ })
+	cb := ecs.NewCommandBuffer()
+	cb.Despawn(cb.Spawn())
+	cb.Flush(em)
+	if destroyed != 1 {
+		t.Errorf("OnDestroy should be called once, but got %d", destroyed)
+	}
+}
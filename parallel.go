@@ -0,0 +1,131 @@
+package ecs
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// defaultChunkSize is the number of entities processed by a worker at once.
+const defaultChunkSize = 256
+
+// defaultWorkerPool is used by ParallelEach if no WorkerPool is given.
+var defaultWorkerPool = NewWorkerPool(0, 0)
+
+// WorkerPool splits the entities into chunks, which are processed by a number of workers in parallel.
+//
+// Each entity is passed to exactly one call of the callback, so inside the callback it is safe to:
+//   - read and modify the Components of the given entity,
+//   - mark its Components as changed by MarkChanged,
+//   - record structural changes into a CommandBuffer,
+//   - read other entities and Resources, which are not modified by the callback.
+//
+// It is not safe to change the entities structurally by Entity.Add, Entity.Set or Entity.Remove,
+// to add or remove entities at the EntityManager or to send Events.
+type WorkerPool struct {
+	workers   int
+	chunkSize int
+}
+
+// NewWorkerPool creates a new WorkerPool and returns its address.
+// It uses one worker per CPU and chunks of 256 entities, if workers or chunkSize are not positive.
+func NewWorkerPool(workers, chunkSize int) *WorkerPool {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if chunkSize <= 0 {
+		chunkSize = defaultChunkSize
+	}
+
+	return &WorkerPool{
+		workers:   workers,
+		chunkSize: chunkSize,
+	}
+}
+
+// Workers returns the maximum number of workers.
+func (p *WorkerPool) Workers() int {
+	return p.workers
+}
+
+// ChunkSize returns the number of entities processed by a worker at once.
+func (p *WorkerPool) ChunkSize() int {
+	return p.chunkSize
+}
+
+// Each calls fn for each entity and returns after all the entities were processed.
+// A panic inside fn is recovered in the worker and raised again in the calling goroutine.
+func (p *WorkerPool) Each(entities []*Entity, fn func(e *Entity)) {
+	chunks := (len(entities) + p.chunkSize - 1) / p.chunkSize
+	if chunks <= 1 || p.workers == 1 {
+		for _, e := range entities {
+			fn(e)
+		}
+		return
+	}
+
+	var (
+		next   atomic.Int64
+		wg     sync.WaitGroup
+		once   sync.Once
+		failed any
+	)
+	for range min(p.workers, chunks) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					once.Do(func() { failed = r })
+				}
+			}()
+			for {
+				chunk := int(next.Add(1) - 1)
+				if chunk >= chunks {
+					return
+				}
+				start := chunk * p.chunkSize
+				for _, e := range entities[start:min(start+p.chunkSize, len(entities))] {
+					fn(e)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if failed != nil {
+		panic(failed)
+	}
+}
+
+// ParallelEach calls fn in parallel for each entity, which Components mask matched.
+// An optional WorkerPool configures the number of workers and the chunk size,
+// see WorkerPool for the operations, which are safe inside fn.
+func ParallelEach(em EntityManager, mask uint64, fn func(e *Entity), pool ...*WorkerPool) {
+	workerPool(pool).Each(em.FilterByMask(mask), fn)
+}
+
+// ParallelEach calls fn in parallel for each matching entity of the Query.
+// An optional WorkerPool configures the number of workers and the chunk size,
+// see WorkerPool for the operations, which are safe inside fn.
+func (q *Query) ParallelEach(fn func(e *Entity), pool ...*WorkerPool) {
+	if !q.filter.hasTicks() {
+		workerPool(pool).Each(q.entities, fn)
+		return
+	}
+
+	workerPool(pool).Each(q.entities, func(e *Entity) {
+		if q.filter.matchesTicks(e) {
+			fn(e)
+		}
+	})
+}
+
+// workerPool returns the optional WorkerPool or the default one.
+func workerPool(pool []*WorkerPool) *WorkerPool {
+	if len(pool) > 0 && pool[0] != nil {
+		return pool[0]
+	}
+
+	return defaultWorkerPool
+}
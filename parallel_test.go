@@ -0,0 +1,95 @@
+package ecs_test
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/bolom009/ecs"
+)
+
+func TestParallelEach_Should_Visit_Each_Entity_Once(t *testing.T) {
+	for name, em := range map[string]ecs.EntityManager{
+		"default":   ecs.NewEntityManager(),
+		"archetype": ecs.NewArchetypeEntityManager(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			em.Add(generateEntities(1000)...)
+			em.NewEntity([]ecs.Component{&position{x: 1, y: 1}})
+			var visited atomic.Int64
+			ecs.ParallelEach(em, 1|2, func(e *ecs.Entity) {
+				visited.Add(1)
+				e.Get(1).(*position).x++
+			}, ecs.NewWorkerPool(4, 16))
+			if visited.Load() != 1000 {
+				t.Errorf("ParallelEach should visit 1000 entities, but got %d", visited.Load())
+			}
+			for _, e := range em.FilterByMask(1 | 2) {
+				if e.Get(1).(*position).x != 2 {
+					t.Fatalf("Each entity should be modified once, but got %v", e.Get(1).(*position).x)
+				}
+			}
+		})
+	}
+}
+
+func TestQuery_ParallelEach_Should_Record_Commands_Concurrently(t *testing.T) {
+	em := ecs.NewArchetypeEntityManager()
+	em.Add(generateEntities(1000)...)
+	q := em.Query(ecs.NewFilter().With(1 | 2))
+	cb := ecs.NewCommandBuffer()
+	q.ParallelEach(func(e *ecs.Entity) {
+		cb.Despawn(e)
+	}, ecs.NewWorkerPool(8, 10))
+	if cb.Len() != 1000 {
+		t.Errorf("CommandBuffer should have 1000 commands, but got %d", cb.Len())
+	}
+	cb.Flush(em)
+	if len(em.Entities()) != 0 || q.Len() != 0 {
+		t.Errorf("All the entities should be despawned, but got %d", len(em.Entities()))
+	}
+}
+
+func TestWorkerPool_Each_Should_Raise_Panic_In_Caller(t *testing.T) {
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Errorf("Panic of a worker should be raised again, but got %v", r)
+		}
+	}()
+	ecs.NewWorkerPool(4, 1).Each(generateEntities(10), func(e *ecs.Entity) {
+		panic("boom")
+	})
+}
+
+func TestNewWorkerPool_Should_Use_Defaults(t *testing.T) {
+	p := ecs.NewWorkerPool(0, 0)
+	if p.Workers() < 1 || p.ChunkSize() != 256 {
+		t.Errorf("WorkerPool should use the defaults, but got %d workers and chunks of %d", p.Workers(), p.ChunkSize())
+	}
+}
+
+func BenchmarkParallelEach(b *testing.B) {
+	em := ecs.NewArchetypeEntityManager()
+	em.Add(generateEntities(100000)...)
+	q := em.Query(ecs.NewFilter().With(1 | 2))
+	move := func(e *ecs.Entity) {
+		pos := e.Get(1).(*position)
+		vel := e.Get(2).(*velocity)
+		pos.x += vel.x * 0.33
+		pos.y += vel.y * 0.33
+	}
+
+	b.Run("serial", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			for e := range q.All() {
+				move(e)
+			}
+		}
+	})
+	b.Run("parallel", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			q.ParallelEach(move)
+		}
+	})
+}
@@ -0,0 +1,157 @@
+package ecs
+
+import (
+	"iter"
+
+	"github.com/bolom009/ecs/intmap"
+)
+
+// Query is a Filter registered at an EntityManager, which keeps the matching entities up to date
+// while entities are added or removed and while their Components change.
+// Iterating a Query costs only the matched entities and allocates nothing.
+type Query struct {
+	filter   Filter
+	entities []*Entity
+	rows     *intmap.Map[EntityID, int]
+	registry *queryRegistry
+}
+
+// Entities returns the matching entities. The slice is owned by the Query
+// and must not be modified. It is only valid until the next structural change.
+// Added and Changed of the Filter are not checked, use All instead.
+func (q *Query) Entities() []*Entity {
+	return q.entities
+}
+
+// All returns an iterator over the matching entities, which allocates nothing.
+// Entities must not be added or removed while iterating.
+func (q *Query) All() iter.Seq[*Entity] {
+	return func(yield func(*Entity) bool) {
+		for _, e := range q.entities {
+			if q.filter.matchesTicks(e) && !yield(e) {
+				return
+			}
+		}
+	}
+}
+
+// Filter returns the Filter of the Query.
+func (q *Query) Filter() Filter {
+	return q.filter
+}
+
+// Len returns the number of matching entities without checking Added and Changed of the Filter.
+func (q *Query) Len() int {
+	return len(q.entities)
+}
+
+// Close unregisters the Query, so that it is no longer updated.
+func (q *Query) Close() {
+	if q.registry != nil {
+		q.registry.unregister(q)
+		q.registry = nil
+	}
+}
+
+// add appends the entity, if it is not already matched.
+func (q *Query) add(e *Entity) {
+	if _, ok := q.rows.Get(e.Id); ok {
+		return
+	}
+
+	q.rows.Put(e.Id, len(q.entities))
+	q.entities = append(q.entities, e)
+}
+
+// remove moves the last entity into the row of the removed one.
+func (q *Query) remove(e *Entity) {
+	row, ok := q.rows.Get(e.Id)
+	if !ok {
+		return
+	}
+
+	last := len(q.entities) - 1
+	if row != last {
+		moved := q.entities[last]
+		q.entities[row] = moved
+		q.rows.Put(moved.Id, row)
+	}
+	q.entities[last] = nil
+	q.entities = q.entities[:last]
+	q.rows.Del(e.Id)
+}
+
+// queryRegistry keeps the registered Queries of an EntityManager up to date.
+type queryRegistry struct {
+	queries []*Query
+}
+
+func newQueryRegistry() *queryRegistry {
+	return &queryRegistry{
+		queries: make([]*Query, 0),
+	}
+}
+
+// register creates a new Query and fills it with the matching entities.
+func (r *queryRegistry) register(filter Filter, entities []*Entity) *Query {
+	q := &Query{
+		filter:   filter,
+		entities: make([]*Entity, 0, len(entities)),
+		rows:     intmap.New[EntityID, int](len(entities)),
+		registry: r,
+	}
+	for _, e := range entities {
+		if filter.Matches(e.Masked) {
+			q.add(e)
+		}
+	}
+	r.queries = append(r.queries, q)
+
+	return q
+}
+
+func (r *queryRegistry) unregister(q *Query) {
+	for i, registered := range r.queries {
+		if registered == q {
+			r.queries = append(r.queries[:i], r.queries[i+1:]...)
+			return
+		}
+	}
+}
+
+// added appends a new entity to the matching Queries.
+func (r *queryRegistry) added(e *Entity) {
+	for _, q := range r.queries {
+		if q.filter.Matches(e.Masked) {
+			q.add(e)
+		}
+	}
+}
+
+// removed removes an entity from all the Queries.
+func (r *queryRegistry) removed(e *Entity) {
+	for _, q := range r.queries {
+		q.remove(e)
+	}
+}
+
+// changed moves an entity in or out of the Queries after its Components changed.
+func (r *queryRegistry) changed(e *Entity, old Bitset) {
+	for _, q := range r.queries {
+		before, now := q.filter.Matches(old), q.filter.Matches(e.Masked)
+		if before && !now {
+			q.remove(e)
+		} else if !before && now {
+			q.add(e)
+		}
+	}
+}
+
+// reset removes all the entities from the Queries, but keeps them registered.
+func (r *queryRegistry) reset() {
+	for _, q := range r.queries {
+		clear(q.entities)
+		q.entities = q.entities[:0]
+		q.rows.Clear()
+	}
+}
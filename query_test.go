@@ -0,0 +1,80 @@
+package ecs_test
+
+import (
+	"testing"
+
+	"github.com/bolom009/ecs"
+)
+
+func TestQuery_Should_Follow_Structural_Changes(t *testing.T) {
+	for name, em := range map[string]ecs.EntityManager{
+		"default":   ecs.NewEntityManager(),
+		"archetype": ecs.NewArchetypeEntityManager(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			e1 := em.NewEntity([]ecs.Component{
+				&mockComponent{name: "position", mask: maskPosition},
+				&mockComponent{name: "velocity", mask: maskVelocity},
+			})
+			q := em.Query(ecs.NewFilter().With(maskPosition | maskVelocity).Without(maskDead))
+			if q.Len() != 1 || q.Entities()[0] != e1 {
+				t.Fatalf("Query should contain the entity %d, but got %v", e1.Id, q.Entities())
+			}
+
+			e2 := em.NewEntity([]ecs.Component{
+				&mockComponent{name: "position", mask: maskPosition},
+			})
+			if q.Len() != 1 {
+				t.Errorf("Query should contain one entity, but got %d", q.Len())
+			}
+
+			e2.Add(&mockComponent{name: "velocity", mask: maskVelocity})
+			if q.Len() != 2 {
+				t.Errorf("Query should contain two entities after adding a Component, but got %d", q.Len())
+			}
+
+			e1.Add(&mockComponent{name: "dead", mask: maskDead})
+			if q.Len() != 1 || q.Entities()[0] != e2 {
+				t.Errorf("Query should only contain the entity %d, but got %v", e2.Id, q.Entities())
+			}
+
+			e1.Remove(maskDead)
+			em.Remove(e2)
+			if q.Len() != 1 || q.Entities()[0] != e1 {
+				t.Errorf("Query should only contain the entity %d, but got %v", e1.Id, q.Entities())
+			}
+
+			em.Reset()
+			if q.Len() != 0 {
+				t.Errorf("Query should be empty after reset, but got %d", q.Len())
+			}
+
+			q.Close()
+			em.NewEntity([]ecs.Component{
+				&mockComponent{name: "position", mask: maskPosition},
+				&mockComponent{name: "velocity", mask: maskVelocity},
+			})
+			if q.Len() != 0 {
+				t.Errorf("Closed Query should not be updated, but got %d", q.Len())
+			}
+		})
+	}
+}
+
+func BenchmarkQuery_Entities(b *testing.B) {
+	em := ecs.NewEntityManager(100000)
+	em.Add(generateEntities(100000)...)
+	q := em.Query(ecs.NewFilter().With(1 | 2))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for b.Loop() {
+		for _, e := range q.Entities() {
+			pos := e.Get(1).(*position)
+			vel := e.Get(2).(*velocity)
+			pos.x += vel.x * 0.33
+			pos.y += vel.y * 0.33
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package ecs
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// RecoverPolicy decides how the Engine handles a panic of a System.
+type RecoverPolicy int
+
+const (
+	// RecoverPropagate does not recover the panic, which is the default.
+	RecoverPropagate RecoverPolicy = iota
+	// RecoverDisable recovers the panic and stops processing the System until the next Setup.
+	// A System, which is not comparable, cannot be disabled and is processed again.
+	RecoverDisable
+	// RecoverRestart recovers the panic and calls Teardown and Setup of the System.
+	// The System is disabled if one of them fails.
+	RecoverRestart
+	// RecoverAbort recovers the panic and stops the Engine like a fatal error.
+	RecoverAbort
+)
+
+// PanicHandler is called by the Engine for each recovered panic with the SystemError,
+// which names the System, and the PanicError containing the panic value and stack.
+type PanicHandler func(failed *SystemError, recovered *PanicError)
+
+// PanicError is the error of a recovered panic, which is recorded as SystemError.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value any
+	// Stack is the stack of the panicking goroutine.
+	Stack []byte
+}
+
+// Error returns the panic value.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value, if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// guard calls fn and returns its error or the recovered panic as PanicError.
+func guard(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return fn()
+}
@@ -0,0 +1,237 @@
+package ecs_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bolom009/ecs"
+)
+
+func TestDefaultEngine_Tick_Should_Propagate_Panic_By_Default(t *testing.T) {
+	sm := ecs.NewSystemManager()
+	sm.Add(&mockupPanicSystem{panics: true})
+	engine := ecs.NewDefaultEngine(ecs.NewEntityManager(), sm)
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Errorf("Panic should be propagated, but got %v", r)
+		}
+	}()
+	engine.Tick()
+}
+
+func TestDefaultEngine_Tick_Should_Disable_System_After_Panic(t *testing.T) {
+	sys := &mockupPanicSystem{panics: true}
+	next := &mockupPanicSystem{}
+	var handled []*ecs.SystemError
+	var stack []byte
+	sm := ecs.NewSystemManager()
+	sm.Add(sys, next)
+	engine := ecs.NewDefaultEngine(ecs.NewEntityManager(), sm,
+		ecs.WithRecoverPolicy(ecs.RecoverDisable),
+		ecs.WithPanicHandler(func(failed *ecs.SystemError, recovered *ecs.PanicError) {
+			handled = append(handled, failed)
+			stack = recovered.Stack
+		}))
+	engine.Setup()
+	engine.Tick()
+	engine.Tick()
+	if sys.processed != 1 || next.processed != 2 {
+		t.Errorf("System should be disabled after the panic, but got %d and %d calls", sys.processed, next.processed)
+	}
+	if len(handled) != 1 || handled[0].System != sys || handled[0].Phase != ecs.PhaseProcess {
+		t.Fatalf("PanicHandler should be called once with the failed System, but got %v", handled)
+	}
+	var recovered *ecs.PanicError
+	if !errors.As(engine.Err(), &recovered) || recovered.Value != "boom" {
+		t.Errorf("Err should contain the recovered panic, but got %v", engine.Err())
+	}
+	if !strings.Contains(string(stack), "mockupPanicSystem") {
+		t.Error("PanicHandler should receive the stack of the panic")
+	}
+	engine.Setup()
+	engine.Tick()
+	if sys.processed != 2 {
+		t.Error("Setup should enable the disabled systems")
+	}
+}
+
+func TestDefaultEngine_Tick_Should_Restart_System_After_Panic(t *testing.T) {
+	sys := &mockupPanicSystem{panics: true}
+	sm := ecs.NewSystemManager()
+	sm.Add(sys)
+	engine := ecs.NewDefaultEngine(ecs.NewEntityManager(), sm, ecs.WithRecoverPolicy(ecs.RecoverRestart, sys))
+	engine.Setup()
+	engine.Tick()
+	if sys.setups != 2 || sys.teardowns != 1 {
+		t.Errorf("System should be restarted, but got %d setups and %d teardowns", sys.setups, sys.teardowns)
+	}
+	sys.panics = false
+	engine.Tick()
+	if sys.processed != 2 {
+		t.Errorf("Restarted System should be processed, but got %d calls", sys.processed)
+	}
+}
+
+func TestDefaultEngine_RunContext_Should_Abort_After_Panic(t *testing.T) {
+	sys := &mockupPanicContextSystem{}
+	other := &mockupPanicSystem{}
+	sm := ecs.NewSystemManager()
+	sm.AddContext(sys)
+	sm.Add(other)
+	engine := ecs.NewDefaultEngine(ecs.NewEntityManager(), sm,
+		ecs.WithRecoverPolicy(ecs.RecoverDisable),
+		ecs.WithRecoverPolicy(ecs.RecoverAbort, sys))
+	engine.Setup()
+	err := engine.RunContext(context.Background())
+	var failed *ecs.SystemError
+	if !errors.As(err, &failed) || failed.System != sys || failed.Name != "*ecs_test.mockupPanicContextSystem" {
+		t.Fatalf("RunContext should return the SystemError of the panic, but got %v", err)
+	}
+	if other.processed != 0 {
+		t.Error("Engine should abort after the panic")
+	}
+	if other.teardowns != 1 {
+		t.Error("RunContext should call Teardown for all the systems after the panic")
+	}
+}
+
+func TestDefaultEngine_Tick_Should_Recover_Panic_Of_Parallel_Stage(t *testing.T) {
+	sys := &mockupPanicContextSystem{}
+	next := &mockupPanicContextSystem{}
+	sm := ecs.NewSystemManager()
+	sm.Schedule(ecs.AdaptContextSystem(sys), ecs.Reads(1))
+	sm.Schedule(ecs.AdaptContextSystem(&mockupPanicContextSystem{continues: true}), ecs.Reads(1))
+	sm.Schedule(ecs.AdaptContextSystem(next), ecs.Writes(1))
+	engine := ecs.NewDefaultEngine(ecs.NewEntityManager(), sm,
+		ecs.WithParallelSystems(),
+		ecs.WithRecoverPolicy(ecs.RecoverDisable))
+	engine.Setup()
+	engine.Tick()
+	if next.processed != 1 {
+		t.Errorf("Engine should continue after the stage, but got %d calls", next.processed)
+	}
+	var failed *ecs.SystemError
+	if !errors.As(engine.Err(), &failed) || failed.System != sys {
+		t.Errorf("Err should contain the panic of the parallel stage, but got %v", engine.Err())
+	}
+}
+
+func TestDefaultEngine_Tick_Should_Raise_Panic_Of_Parallel_Stage_In_Caller(t *testing.T) {
+	sm := ecs.NewSystemManager()
+	sm.Schedule(ecs.AdaptContextSystem(&mockupPanicContextSystem{}), ecs.Reads(1))
+	sm.Schedule(ecs.AdaptContextSystem(&mockupPanicContextSystem{continues: true}), ecs.Reads(1))
+	engine := ecs.NewDefaultEngine(ecs.NewEntityManager(), sm, ecs.WithParallelSystems())
+	defer func() {
+		if r := recover(); r != errMockup {
+			t.Errorf("Panic of the stage should be raised again, but got %v", r)
+		}
+	}()
+	engine.Tick()
+}
+
+func TestDefaultEngine_Tick_Should_Support_Systems_Which_Are_Not_Comparable(t *testing.T) {
+	processed := 0
+	sys := &mockupPanicSystem{}
+	sm := ecs.NewSystemManager()
+	sm.Add(sys, mockupValueSystem{names: []string{"value"}, processed: &processed, panics: true})
+	engine := ecs.NewDefaultEngine(ecs.NewEntityManager(), sm,
+		ecs.WithRecoverPolicy(ecs.RecoverDisable),
+		ecs.WithRecoverPolicy(ecs.RecoverRestart, sys))
+	engine.Setup()
+	engine.Tick()
+	engine.Tick()
+	if processed != 2 || sys.processed != 2 {
+		t.Errorf("Systems should be processed in each tick, but got %d and %d calls", processed, sys.processed)
+	}
+	if errs := engine.Err().(interface{ Unwrap() []error }).Unwrap(); len(errs) != 2 {
+		t.Errorf("Err should contain the recovered panic per tick, but got %v", engine.Err())
+	}
+}
+
+func TestDefaultEngine_Teardown_Should_Recover_Panic(t *testing.T) {
+	sys := &mockupPanicSystem{panicsOnTeardown: true}
+	next := &mockupPanicSystem{}
+	sm := ecs.NewSystemManager()
+	sm.Add(sys, next)
+	engine := ecs.NewDefaultEngine(ecs.NewEntityManager(), sm, ecs.WithRecoverPolicy(ecs.RecoverDisable))
+	engine.Setup()
+	engine.Teardown()
+	if next.teardowns != 1 {
+		t.Error("Teardown should be called for the other systems")
+	}
+	var failed *ecs.SystemError
+	if !errors.As(engine.Err(), &failed) || failed.Phase != ecs.PhaseTeardown {
+		t.Errorf("Err should contain the panic of the teardown, but got %v", engine.Err())
+	}
+}
+
+/*
+       _   _ _
+ _   _| |_(_) |___
+| | | | __| | / __|
+| |_| | |_| | \__ \
+ \__,_|\__|_|_|___/
+*/
+
+// mockupPanicSystem panics in Process or Teardown and counts the calls.
+type mockupPanicSystem struct {
+	panics           bool
+	panicsOnTeardown bool
+	processed        int
+	setups           int
+	teardowns        int
+}
+
+func (s *mockupPanicSystem) Process(entityManager ecs.EntityManager) (state int) {
+	s.processed++
+	if s.panics {
+		panic("boom")
+	}
+	return ecs.StateEngineContinue
+}
+
+func (s *mockupPanicSystem) Setup() {
+	s.setups++
+}
+
+func (s *mockupPanicSystem) Teardown() {
+	s.teardowns++
+	if s.panicsOnTeardown {
+		panic("boom")
+	}
+}
+
+// mockupPanicContextSystem panics with an error unless it continues.
+type mockupPanicContextSystem struct {
+	continues bool
+	processed int
+}
+
+func (s *mockupPanicContextSystem) ProcessContext(ctx *ecs.Context) (state int) {
+	s.processed++
+	if !s.continues {
+		panic(errMockup)
+	}
+	return ecs.StateEngineContinue
+}
+func (s *mockupPanicContextSystem) Setup()    {}
+func (s *mockupPanicContextSystem) Teardown() {}
+
+// mockupValueSystem is not comparable, so it cannot be used as key of a map.
+type mockupValueSystem struct {
+	names     []string
+	processed *int
+	panics    bool
+}
+
+func (s mockupValueSystem) Process(entityManager ecs.EntityManager) (state int) {
+	*s.processed++
+	if s.panics {
+		panic("boom")
+	}
+	return ecs.StateEngineContinue
+}
+func (s mockupValueSystem) Setup()    {}
+func (s mockupValueSystem) Teardown() {}
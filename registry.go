@@ -0,0 +1,160 @@
+package ecs
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ErrComponentCollision is returned if a bit is already used by another Component.
+var ErrComponentCollision = errors.New("ecs: component collision")
+
+// defaultRegistry is used by BitOf and MaskOf.
+var defaultRegistry = NewComponentRegistry()
+
+// ComponentRegistry assigns the bits of the Component types and names.
+// It reports collisions between Components using the same bit
+// and can be queried for the name of a bit for debugging.
+// It is not connected to an EntityManager, Components are only checked by RegisterComponent.
+type ComponentRegistry struct {
+	mutex  sync.RWMutex
+	types  map[reflect.Type]uint
+	names  map[string]uint
+	owners map[uint]string
+}
+
+// NewComponentRegistry creates a new ComponentRegistry and returns its address.
+func NewComponentRegistry() *ComponentRegistry {
+	return &ComponentRegistry{
+		types:  make(map[reflect.Type]uint),
+		names:  make(map[string]uint),
+		owners: make(map[uint]string),
+	}
+}
+
+// DefaultRegistry returns the ComponentRegistry used by BitOf and MaskOf.
+// It is shared by the whole process, so the bits depend on the order, in which
+// the types are used first. Do not persist them, e.g. in saved games.
+func DefaultRegistry() *ComponentRegistry {
+	return defaultRegistry
+}
+
+// RegisterType returns the bit of the type T and assigns the next free bit on the first call.
+func RegisterType[T any](r *ComponentRegistry) uint {
+	t := componentType(reflect.TypeFor[T]())
+	r.mutex.RLock()
+	bit, ok := r.types[t]
+	r.mutex.RUnlock()
+	if ok {
+		return bit
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	bit, ok = r.types[t]
+	if !ok {
+		bit = r.claim(t.String())
+		r.types[t] = bit
+	}
+	return bit
+}
+
+// RegisterName returns the bit of the name and assigns the next free bit on the first call.
+// It is used for ComponentWithName, which are identified by their name instead of their type.
+func (r *ComponentRegistry) RegisterName(name string) uint {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	bit, ok := r.names[name]
+	if !ok {
+		bit = r.claim(name)
+		r.names[name] = bit
+	}
+	return bit
+}
+
+// RegisterComponent registers the bits the Component declares by Mask() or Bit().
+// A ComponentWithName is identified by its name and any other Component by its type.
+// It returns an ErrComponentCollision if a bit is already used by another Component.
+func (r *ComponentRegistry) RegisterComponent(c Component) error {
+	bits := maskSlice([]Component{c})
+	if bits.IsZero() {
+		return fmt.Errorf("%w: %T does not declare any bit", ErrComponentCollision, c)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	label := componentLabel(c)
+	for bit := range bits.All() {
+		if owner, ok := r.owners[bit]; ok && owner != label {
+			return fmt.Errorf("%w: bit %d of %s is already used by %s", ErrComponentCollision, bit, label, owner)
+		}
+	}
+
+	for bit := range bits.All() {
+		r.owners[bit] = label
+		if cn, ok := c.(ComponentWithName); ok {
+			r.names[cn.Name()] = bit
+		} else {
+			r.types[componentType(reflect.TypeOf(c))] = bit
+		}
+	}
+	return nil
+}
+
+// Name returns the name of the Component using the bit or an empty string.
+func (r *ComponentRegistry) Name(bit uint) string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.owners[bit]
+}
+
+// Names returns the names of all the Components of the Bitset.
+func (r *ComponentRegistry) Names(mask Bitset) []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	names := make([]string, 0, mask.Count())
+	for bit := range mask.All() {
+		if name, ok := r.owners[bit]; ok {
+			names = append(names, name)
+		} else {
+			names = append(names, fmt.Sprintf("bit(%d)", bit))
+		}
+	}
+	return names
+}
+
+// claim assigns the lowest free bit to the owner.
+func (r *ComponentRegistry) claim(owner string) uint {
+	bit := uint(0)
+	for {
+		if _, ok := r.owners[bit]; !ok {
+			r.owners[bit] = owner
+			return bit
+		}
+		bit++
+	}
+}
+
+// componentType returns the element type of a pointer, so that T and *T share the same bit.
+func componentType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Pointer {
+		return t.Elem()
+	}
+
+	return t
+}
+
+// componentLabel returns the name of the Component used in the ComponentRegistry.
+func componentLabel(c Component) string {
+	if cn, ok := c.(ComponentWithName); ok {
+		return cn.Name()
+	}
+
+	return componentType(reflect.TypeOf(c)).String()
+}
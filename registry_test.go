@@ -0,0 +1,66 @@
+package ecs_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bolom009/ecs"
+)
+
+func TestComponentRegistry_RegisterType_Should_Assign_Stable_Bits(t *testing.T) {
+	r := ecs.NewComponentRegistry()
+	p := ecs.RegisterType[position](r)
+	v := ecs.RegisterType[velocity](r)
+	if p != 0 || v != 1 {
+		t.Errorf("Bits should be 0 and 1, but got %d and %d", p, v)
+	}
+	if bit := ecs.RegisterType[*position](r); bit != p {
+		t.Errorf("Pointer type should share the bit %d, but got %d", p, bit)
+	}
+	if name := r.Name(v); name != "ecs_test.velocity" {
+		t.Errorf("Name should be ecs_test.velocity, but got %s", name)
+	}
+}
+
+func TestComponentRegistry_RegisterName_Should_Skip_Used_Bits(t *testing.T) {
+	r := ecs.NewComponentRegistry()
+	if err := r.RegisterComponent(&position{}); err != nil {
+		t.Fatal(err)
+	}
+	if bit := r.RegisterName("sprite"); bit != 1 {
+		t.Errorf("Bit should be 1, but got %d", bit)
+	}
+	if bit := r.RegisterName("sprite"); bit != 1 {
+		t.Errorf("Bit should stay 1, but got %d", bit)
+	}
+	if name := r.Name(1); name != "sprite" {
+		t.Errorf("Name should be sprite, but got %s", name)
+	}
+}
+
+func TestComponentRegistry_RegisterComponent_Should_Report_Collisions(t *testing.T) {
+	r := ecs.NewComponentRegistry()
+	if err := r.RegisterComponent(&position{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RegisterComponent(&position{}); err != nil {
+		t.Errorf("Registering the same Component twice should succeed, but got %v", err)
+	}
+	err := r.RegisterComponent(&mockComponent{name: "transform", mask: 1})
+	if !errors.Is(err, ecs.ErrComponentCollision) {
+		t.Errorf("Error should be ErrComponentCollision, but got %v", err)
+	}
+	if err := r.RegisterComponent(&mockComponent{name: "size", mask: 0}); !errors.Is(err, ecs.ErrComponentCollision) {
+		t.Errorf("Component without a bit should be rejected, but got %v", err)
+	}
+}
+
+func TestComponentRegistry_Names_Should_Describe_A_Bitset(t *testing.T) {
+	r := ecs.NewComponentRegistry()
+	_ = r.RegisterComponent(&position{})
+	_ = r.RegisterComponent(&mockWideComponent{bit: 70, name: "wide"})
+	names := r.Names(ecs.NewBitset(0, 5, 70))
+	if len(names) != 3 || names[0] != "ecs_test.position" || names[1] != "bit(5)" || names[2] != "wide" {
+		t.Errorf("Names should describe all the bits, but got %v", names)
+	}
+}
@@ -0,0 +1,70 @@
+package ecs
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Resources stores a single value of each type, which is shared by all the systems of a world,
+// e.g. the delta time, the input state or a random number generator.
+type Resources struct {
+	mutex  sync.RWMutex
+	values map[reflect.Type]any
+}
+
+func newResources() *Resources {
+	return &Resources{
+		values: make(map[reflect.Type]any),
+	}
+}
+
+// Len returns the number of stored resources.
+func (r *Resources) Len() int {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return len(r.values)
+}
+
+// SetResource stores the value as the resource of type T of the EntityManager
+// and replaces the previous one.
+func SetResource[T any](em EntityManager, value T) {
+	SetResourceOf(em.Resources(), value)
+}
+
+// Resource returns the resource of type T of the EntityManager
+// or its zero value and false, if it was not set.
+func Resource[T any](em EntityManager) (T, bool) {
+	return ResourceOf[T](em.Resources())
+}
+
+// RemoveResource removes the resource of type T of the EntityManager.
+func RemoveResource[T any](em EntityManager) {
+	RemoveResourceOf[T](em.Resources())
+}
+
+// SetResourceOf stores the value as the resource of type T and replaces the previous one,
+// e.g. in the Resources of a Context.
+func SetResourceOf[T any](r *Resources, value T) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.values[reflect.TypeFor[T]()] = value
+}
+
+// ResourceOf returns the resource of type T or its zero value and false, if it was not set.
+func ResourceOf[T any](r *Resources) (T, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	value, ok := r.values[reflect.TypeFor[T]()].(T)
+	return value, ok
+}
+
+// RemoveResourceOf removes the resource of type T.
+func RemoveResourceOf[T any](r *Resources) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.values, reflect.TypeFor[T]())
+}
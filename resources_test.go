@@ -0,0 +1,101 @@
+package ecs_test
+
+import (
+	"testing"
+
+	"github.com/bolom009/ecs"
+)
+
+func TestResource_Should_Return_Stored_Value(t *testing.T) {
+	for name, em := range map[string]ecs.EntityManager{
+		"default":   ecs.NewEntityManager(),
+		"archetype": ecs.NewArchetypeEntityManager(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, ok := ecs.Resource[deltaTime](em); ok {
+				t.Error("Resource should not be found before it was set")
+			}
+			ecs.SetResource(em, deltaTime(0.5))
+			ecs.SetResource(em, &input{jump: true})
+			if dt, ok := ecs.Resource[deltaTime](em); !ok || dt != 0.5 {
+				t.Errorf("Resource should be 0.5, but got %v", dt)
+			}
+			ecs.SetResource(em, deltaTime(0.25))
+			if dt, _ := ecs.Resource[deltaTime](em); dt != 0.25 {
+				t.Errorf("Resource should be replaced, but got %v", dt)
+			}
+			if in, ok := ecs.Resource[*input](em); !ok || !in.jump {
+				t.Error("Resources of different types should be separated")
+			}
+			em.Reset()
+			if em.Resources().Len() != 2 {
+				t.Errorf("Reset should keep the resources, but got %d", em.Resources().Len())
+			}
+			ecs.RemoveResource[*input](em)
+			if _, ok := ecs.Resource[*input](em); ok {
+				t.Error("Removed resource should not be found")
+			}
+		})
+	}
+}
+
+func TestResource_Should_Be_Shared_Between_Systems(t *testing.T) {
+	em := ecs.NewEntityManager()
+	reader := &mockupResourceSystem{}
+	sm := ecs.NewSystemManager()
+	sm.Add(&mockupResourceSystem{write: true}, reader)
+	ecs.NewDefaultEngine(em, sm).Tick()
+	if reader.value != 1 {
+		t.Errorf("Reading system should get the resource, but got %v", reader.value)
+	}
+}
+
+func TestResourceOf_Should_Access_Resources_Of_Context(t *testing.T) {
+	em := ecs.NewEntityManager()
+	sys := &mockupContextSystem{}
+	sm := ecs.NewSystemManager()
+	sm.AddContext(sys)
+	ecs.NewDefaultEngine(em, sm).Tick()
+	ecs.SetResourceOf(sys.resources, deltaTime(0.5))
+	if dt, ok := ecs.Resource[deltaTime](em); !ok || dt != 0.5 {
+		t.Errorf("Resource should be stored in the World, but got %v", dt)
+	}
+	if dt, ok := ecs.ResourceOf[deltaTime](sys.resources); !ok || dt != 0.5 {
+		t.Errorf("Resource should be 0.5, but got %v", dt)
+	}
+	ecs.RemoveResourceOf[deltaTime](sys.resources)
+	if _, ok := ecs.ResourceOf[deltaTime](sys.resources); ok {
+		t.Error("Removed resource should not be found")
+	}
+}
+
+/*
+       _   _ _
+ _   _| |_(_) |___
+| | | | __| | / __|
+| |_| | |_| | \__ \
+ \__,_|\__|_|_|___/
+*/
+
+type deltaTime float64
+
+type input struct {
+	jump bool
+}
+
+// mockupResourceSystem writes or reads the deltaTime resource.
+type mockupResourceSystem struct {
+	write bool
+	value deltaTime
+}
+
+func (s *mockupResourceSystem) Process(em ecs.EntityManager) (state int) {
+	if s.write {
+		ecs.SetResource(em, deltaTime(1))
+	} else {
+		s.value, _ = ecs.Resource[deltaTime](em)
+	}
+	return ecs.StateEngineContinue
+}
+func (s *mockupResourceSystem) Setup()    {}
+func (s *mockupResourceSystem) Teardown() {}
@@ -0,0 +1,224 @@
+package ecs
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrSystemCycle is returned if the Before and After constraints of the systems contain a cycle.
+var ErrSystemCycle = errors.New("ecs: system cycle")
+
+// SystemOption declares the labels of a System and its order relative to other labels.
+type SystemOption func(s *scheduledSystem)
+
+// Label sets the labels of the System, which are referenced by Before and After of other systems.
+func Label(labels ...string) SystemOption {
+	return func(s *scheduledSystem) {
+		s.labels = append(s.labels, labels...)
+	}
+}
+
+// Before processes the System before all the systems having one of the labels.
+func Before(labels ...string) SystemOption {
+	return func(s *scheduledSystem) {
+		s.before = append(s.before, labels...)
+	}
+}
+
+// After processes the System after all the systems having one of the labels.
+func After(labels ...string) SystemOption {
+	return func(s *scheduledSystem) {
+		s.after = append(s.after, labels...)
+	}
+}
+
+// Reads declares the Components of the mask, which the System reads.
+// Systems declaring their access can be processed in parallel by the Engine.
+func Reads(mask uint64) SystemOption {
+	return ReadsBits(BitsetFromMask(mask))
+}
+
+// ReadsBits declares the Components of the Bitset, which the System reads.
+func ReadsBits(mask Bitset) SystemOption {
+	return func(s *scheduledSystem) {
+		s.reads = s.reads.Or(mask)
+		s.declared = true
+	}
+}
+
+// Writes declares the Components of the mask, which the System writes.
+// Systems declaring their access can be processed in parallel by the Engine.
+func Writes(mask uint64) SystemOption {
+	return WritesBits(BitsetFromMask(mask))
+}
+
+// WritesBits declares the Components of the Bitset, which the System writes.
+func WritesBits(mask Bitset) SystemOption {
+	return func(s *scheduledSystem) {
+		s.writes = s.writes.Or(mask)
+		s.declared = true
+	}
+}
+
+// scheduledSystem is a System with its labels, ordering constraints and Component access.
+type scheduledSystem struct {
+	system   System
+	labels   []string
+	before   []string
+	after    []string
+	reads    Bitset
+	writes   Bitset
+	declared bool
+}
+
+// parallel reports whether the systems can be processed at the same time:
+// both declared their access, neither writes what the other one accesses
+// and they are not ordered by Before or After.
+func (s *scheduledSystem) parallel(other *scheduledSystem) bool {
+	return s.declared && other.declared &&
+		!s.writes.Intersects(other.reads.Or(other.writes)) &&
+		!other.writes.Intersects(s.reads) &&
+		!s.ordered(other) && !other.ordered(s)
+}
+
+// ordered reports whether the system has a Before or After constraint referencing the other one.
+func (s *scheduledSystem) ordered(other *scheduledSystem) bool {
+	for _, label := range other.labels {
+		if slices.Contains(s.before, label) || slices.Contains(s.after, label) {
+			return true
+		}
+	}
+	return false
+}
+
+// name returns the first label of the System or its type for debugging.
+func (s *scheduledSystem) name() string {
+	if len(s.labels) > 0 {
+		return s.labels[0]
+	}
+
+	return systemName(s.system)
+}
+
+// schedule sorts the systems topologically by their Before and After constraints.
+// Systems without constraints between each other keep the order of their registration.
+// Labels without any system are ignored.
+func schedule(systems []*scheduledSystem) ([]*scheduledSystem, error) {
+	byLabel := make(map[string][]int)
+	for i, s := range systems {
+		for _, label := range s.labels {
+			byLabel[label] = append(byLabel[label], i)
+		}
+	}
+
+	// edges[i] contains the systems, which must be processed after the system i.
+	edges := make([][]int, len(systems))
+	degree := make([]int, len(systems))
+	link := func(from, to int) {
+		if from != to {
+			edges[from] = append(edges[from], to)
+			degree[to]++
+		}
+	}
+	for i, s := range systems {
+		for _, label := range s.before {
+			for _, j := range byLabel[label] {
+				link(i, j)
+			}
+		}
+		for _, label := range s.after {
+			for _, j := range byLabel[label] {
+				link(j, i)
+			}
+		}
+	}
+
+	sorted := make([]*scheduledSystem, 0, len(systems))
+	done := make([]bool, len(systems))
+	for len(sorted) < len(systems) {
+		// Take the first registered system, which has no pending predecessor.
+		next := -1
+		for i := range systems {
+			if !done[i] && degree[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			return nil, cycleError(systems, edges, done)
+		}
+
+		done[next] = true
+		sorted = append(sorted, systems[next])
+		for _, j := range edges[next] {
+			degree[j]--
+		}
+	}
+	return sorted, nil
+}
+
+// stages splits the sorted systems into stages of consecutive systems, which can be processed in parallel.
+// Systems without declared access are processed in a stage of their own.
+func stages(sorted []*scheduledSystem) [][]*scheduledSystem {
+	result := make([][]*scheduledSystem, 0, len(sorted))
+	for _, s := range sorted {
+		if n := len(result); n > 0 && joinable(result[n-1], s) {
+			result[n-1] = append(result[n-1], s)
+			continue
+		}
+		result = append(result, []*scheduledSystem{s})
+	}
+	return result
+}
+
+// joinable reports whether the system can be processed in parallel to all the systems of the stage.
+func joinable(stage []*scheduledSystem, s *scheduledSystem) bool {
+	for _, other := range stage {
+		if !s.parallel(other) {
+			return false
+		}
+	}
+	return true
+}
+
+// cycleError follows the predecessors of the remaining systems until a system is visited twice.
+// Each remaining system has a remaining predecessor, otherwise it would have been sorted.
+func cycleError(systems []*scheduledSystem, edges [][]int, done []bool) error {
+	preds := make([][]int, len(systems))
+	for from, to := range edges {
+		for _, j := range to {
+			preds[j] = append(preds[j], from)
+		}
+	}
+
+	current := 0
+	for done[current] {
+		current++
+	}
+
+	visited := make(map[int]int)
+	path := make([]int, 0)
+	for {
+		if at, ok := visited[current]; ok {
+			path = append(path[at:], current)
+			break
+		}
+		visited[current] = len(path)
+		path = append(path, current)
+		for _, j := range preds[current] {
+			if !done[j] {
+				current = j
+				break
+			}
+		}
+	}
+
+	// The path follows the predecessors, so it is reversed to show the processing order.
+	names := make([]string, len(path))
+	for i, index := range path {
+		names[len(path)-1-i] = systems[index].name()
+	}
+	return fmt.Errorf("%w: %s", ErrSystemCycle, strings.Join(names, " -> "))
+}
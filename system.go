@@ -1,5 +1,13 @@
 package ecs
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
 // System implements the behaviour of an entity by modifying the state,
 // which is stored in each component of the entity.
 type System interface {
@@ -13,3 +21,170 @@ type System interface {
 	// It is used to clean up the state of the system.
 	Teardown()
 }
+
+// Context contains the state of the current frame, which is passed to a ContextSystem.
+// It is reused by the Engine for each call and must not be kept after ProcessContext returns.
+type Context struct {
+	// Context is cancelled, if the Engine should stop.
+	context.Context
+	// DeltaTime is the time elapsed since the previous tick
+	// or the fixed step while processing the fixed-update systems.
+	DeltaTime time.Duration
+	// Alpha is the progress between the previous and the next fixed step from 0 to 1,
+	// which is used to interpolate the state of the fixed-update systems while rendering.
+	Alpha float64
+	// Tick is the current world tick of the EntityManager.
+	Tick uint64
+	// World is the EntityManager processed by the Engine.
+	World EntityManager
+	// Commands records the structural changes, which are applied after the system.
+	Commands *CommandBuffer
+	// Events contains the Events of the Engine.
+	Events *EventBus
+	// Resources contains the resources of the World, use SetResourceOf and ResourceOf to access them.
+	Resources *Resources
+}
+
+// ContextSystem is a System, which receives the Context of the current frame instead of
+// the EntityManager only. It is added by SystemManager.AddContext.
+type ContextSystem interface {
+	// Setup is called once before the first call to ProcessContext.
+	Setup()
+	// ProcessContext is called once per tick with the Context of the current frame.
+	ProcessContext(ctx *Context) (state int)
+	// Teardown is called once after the last call to ProcessContext.
+	Teardown()
+}
+
+// contextSystem adapts a ContextSystem to a System, so that it can be stored by a SystemManager.
+type contextSystem struct {
+	ContextSystem
+}
+
+// AdaptContextSystem returns a System, which calls the ContextSystem.
+// It is used by SystemManager.AddContext to store the ContextSystem next to the other systems.
+func AdaptContextSystem(system ContextSystem) System {
+	return &contextSystem{system}
+}
+
+// Process calls ProcessContext with a Context of the EntityManager, which Commands are applied
+// afterwards and which Events are not shared with any other System.
+// The Engine calls ProcessContext directly with the Context of the current frame.
+func (s *contextSystem) Process(entityManager EntityManager) (state int) {
+	ctx := newContext(entityManager)
+	state = s.ProcessContext(ctx)
+	ctx.Commands.Flush(entityManager)
+	return state
+}
+
+// newContext creates the Context of a System processed without an Engine.
+func newContext(entityManager EntityManager) *Context {
+	return &Context{
+		Context:   context.Background(),
+		Tick:      worldTick(entityManager),
+		World:     entityManager,
+		Commands:  NewCommandBuffer(),
+		Events:    NewEventBus(),
+		Resources: entityManager.Resources(),
+	}
+}
+
+// ErrorSystem is a System, which reports its failures as errors instead of panicking.
+// It is added by SystemManager.Add(AdaptErrorSystem(system)) and receives the Context
+// of the current frame like a ContextSystem.
+// The Engine records the errors as SystemError, see Engine.Err.
+type ErrorSystem interface {
+	// Setup is called once before the first call to Process.
+	// An error prevents the Engine from processing any System.
+	Setup() error
+	// Process is called once per tick with the Context of the current frame.
+	// It returns ErrEngineStop to stop the Engine and a Fatal error to abort it.
+	// Other errors are recorded and the Engine continues.
+	Process(ctx *Context) error
+	// Teardown is called once after the last call to Process.
+	Teardown() error
+}
+
+// errorSystem adapts an ErrorSystem to a System, so that it can be stored by a SystemManager.
+type errorSystem struct {
+	system ErrorSystem
+	// err is the error of Setup, which stops the Engine at the next Process.
+	err error
+}
+
+// AdaptErrorSystem returns a System, which calls the ErrorSystem.
+// The Engine calls the ErrorSystem directly and records its errors.
+// Processed by another Engine, the System stops it in the same cases as the Engine:
+// if Setup failed or if Process returns ErrEngineStop or a Fatal error.
+// The other errors are dropped, as they cannot be recorded.
+func AdaptErrorSystem(system ErrorSystem) System {
+	return &errorSystem{system: system}
+}
+
+// Setup calls Setup of the ErrorSystem and keeps its error for Process.
+func (s *errorSystem) Setup() {
+	s.err = s.system.Setup()
+}
+
+// Process calls Process of the ErrorSystem with a Context of the EntityManager like contextSystem.
+// It returns StateEngineStop if Setup failed or if the ErrorSystem returns ErrEngineStop or a Fatal error.
+func (s *errorSystem) Process(entityManager EntityManager) (state int) {
+	if s.err != nil {
+		return StateEngineStop
+	}
+
+	ctx := newContext(entityManager)
+	err := s.system.Process(ctx)
+	ctx.Commands.Flush(entityManager)
+	if errors.Is(err, ErrEngineStop) || IsFatal(err) {
+		return StateEngineStop
+	}
+	return StateEngineContinue
+}
+
+// Teardown calls Teardown of the ErrorSystem and drops its error.
+func (s *errorSystem) Teardown() {
+	s.system.Teardown()
+}
+
+// setup calls Setup of the System and returns the error of an ErrorSystem.
+func setup(system System) error {
+	if s, ok := system.(*errorSystem); ok {
+		return s.system.Setup()
+	}
+
+	system.Setup()
+	return nil
+}
+
+// teardown calls Teardown of the System and returns the error of an ErrorSystem.
+func teardown(system System) error {
+	if s, ok := system.(*errorSystem); ok {
+		return s.system.Teardown()
+	}
+
+	system.Teardown()
+	return nil
+}
+
+// adapted returns the ContextSystem or ErrorSystem adapted by the System or the System itself.
+func adapted(system System) any {
+	switch s := system.(type) {
+	case *contextSystem:
+		return s.ContextSystem
+	case *errorSystem:
+		return s.system
+	}
+	return system
+}
+
+// isComparable reports whether the system can be used as key of a map,
+// which panics for a system of a type, which is not comparable.
+func isComparable(system any) bool {
+	return reflect.TypeOf(system).Comparable()
+}
+
+// systemName returns the type of the System or of the adapted system for debugging.
+func systemName(system System) string {
+	return fmt.Sprintf("%T", adapted(system))
+}
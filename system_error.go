@@ -0,0 +1,71 @@
+package ecs
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrEngineStop is returned by an ErrorSystem to stop the Engine like StateEngineStop.
+// It is not recorded as an error.
+var ErrEngineStop = errors.New("ecs: engine stop")
+
+// Phases of a System, in which a SystemError occurred.
+const (
+	PhaseSetup    = "setup"
+	PhaseProcess  = "process"
+	PhaseTeardown = "teardown"
+)
+
+// SystemError is recorded by the Engine if an ErrorSystem fails or a panic of a System is recovered.
+// It is found in the error returned by Engine.Err by errors.As.
+type SystemError struct {
+	// System is the failed System, ContextSystem or ErrorSystem.
+	System any
+	// Name is the type of the failed System.
+	Name string
+	// Phase is one of PhaseSetup, PhaseProcess or PhaseTeardown.
+	Phase string
+	// Tick is the world tick, in which the System failed.
+	Tick uint64
+	// Err is the error returned by the System or the PanicError of a recovered panic.
+	Err error
+}
+
+// Error returns the name of the System, the phase and the error.
+func (e *SystemError) Error() string {
+	return fmt.Sprintf("ecs: system %s failed in %s at tick %d: %v", e.Name, e.Phase, e.Tick, e.Err)
+}
+
+// Unwrap returns the error returned by the System.
+func (e *SystemError) Unwrap() error {
+	return e.Err
+}
+
+// Fatal marks the error, so that the Engine stops after the System returned it.
+// It returns nil if err is nil.
+func Fatal(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &fatalError{err}
+}
+
+// IsFatal reports whether the error or any error wrapped by it was marked by Fatal.
+func IsFatal(err error) bool {
+	var fatal *fatalError
+	return errors.As(err, &fatal)
+}
+
+// fatalError is an error, which stops the Engine.
+type fatalError struct {
+	err error
+}
+
+func (e *fatalError) Error() string {
+	return e.err.Error()
+}
+
+func (e *fatalError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,174 @@
+package ecs_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bolom009/ecs"
+)
+
+var errMockup = errors.New("mockup")
+
+func TestDefaultEngine_Tick_Should_Record_Error_And_Continue(t *testing.T) {
+	sys := &mockupErrorSystem{process: errMockup}
+	next := &mockupSystem{}
+	sm := ecs.NewSystemManager()
+	sm.Add(ecs.AdaptErrorSystem(sys), next)
+	engine := ecs.NewDefaultEngine(ecs.NewEntityManager(), sm)
+	engine.Setup()
+	engine.Tick()
+	engine.Tick()
+	if next.Counter != 2 {
+		t.Errorf("Engine should continue after an error, but got %d ticks", next.Counter)
+	}
+	err := engine.Err()
+	if !errors.Is(err, errMockup) {
+		t.Fatalf("Err should contain the error, but got %v", err)
+	}
+	var failed *ecs.SystemError
+	if !errors.As(err, &failed) {
+		t.Fatalf("Err should contain a SystemError, but got %v", err)
+	}
+	if failed.System != sys || failed.Name != "*ecs_test.mockupErrorSystem" || failed.Phase != ecs.PhaseProcess || failed.Tick != 1 {
+		t.Errorf("SystemError should name the failed System, but got %v", failed)
+	}
+	if errs := err.(interface{ Unwrap() []error }).Unwrap(); len(errs) != 2 {
+		t.Errorf("Err should contain an error per tick, but got %d", len(errs))
+	}
+}
+
+func TestDefaultEngine_RunContext_Should_Return_Fatal_Error(t *testing.T) {
+	sys := &mockupErrorSystem{process: ecs.Fatal(errMockup)}
+	next := &mockupSystem{}
+	sm := ecs.NewSystemManager()
+	sm.Add(ecs.AdaptErrorSystem(sys), next)
+	engine := ecs.NewDefaultEngine(ecs.NewEntityManager(), sm)
+	engine.Setup()
+	err := engine.RunContext(context.Background())
+	var failed *ecs.SystemError
+	if !errors.As(err, &failed) || !ecs.IsFatal(err) || !errors.Is(err, errMockup) {
+		t.Fatalf("RunContext should return the fatal SystemError, but got %v", err)
+	}
+	if next.Counter != 0 || sys.processed != 1 {
+		t.Errorf("Engine should stop after the fatal error, but got %d and %d calls", sys.processed, next.Counter)
+	}
+	if sys.teardowns != 1 {
+		t.Error("RunContext should call Teardown after a fatal error")
+	}
+	engine.Tick()
+	if sys.processed != 1 {
+		t.Error("Engine should not process any System after a fatal error")
+	}
+}
+
+func TestDefaultEngine_Setup_Should_Prevent_Processing_If_ErrorSystem_Fails(t *testing.T) {
+	sys := &mockupErrorSystem{setup: errMockup}
+	sm := ecs.NewSystemManager()
+	sm.Add(ecs.AdaptErrorSystem(sys))
+	engine := ecs.NewDefaultEngine(ecs.NewEntityManager(), sm)
+	engine.Setup()
+	engine.Run()
+	var failed *ecs.SystemError
+	if !errors.As(engine.Err(), &failed) || failed.Phase != ecs.PhaseSetup {
+		t.Fatalf("Err should contain the setup error, but got %v", engine.Err())
+	}
+	if sys.processed != 0 {
+		t.Errorf("Engine should not process any System after a failed setup, but got %d", sys.processed)
+	}
+	sys.setup = nil
+	sys.process = ecs.ErrEngineStop
+	engine.Setup()
+	engine.Run()
+	if engine.Err() != nil || sys.processed != 1 {
+		t.Errorf("Setup should clear the errors, but got %v", engine.Err())
+	}
+}
+
+func TestDefaultEngine_Teardown_Should_Record_Error(t *testing.T) {
+	sys := &mockupErrorSystem{teardown: errMockup}
+	sm := ecs.NewSystemManager()
+	sm.Add(ecs.AdaptErrorSystem(sys))
+	engine := ecs.NewDefaultEngine(ecs.NewEntityManager(), sm)
+	engine.Setup()
+	engine.Teardown()
+	var failed *ecs.SystemError
+	if !errors.As(engine.Err(), &failed) || failed.Phase != ecs.PhaseTeardown {
+		t.Errorf("Err should contain the teardown error, but got %v", engine.Err())
+	}
+}
+
+func TestDefaultEngine_Tick_Should_Record_Errors_Of_Parallel_Stage(t *testing.T) {
+	s1 := &mockupErrorSystem{process: errMockup}
+	s2 := &mockupErrorSystem{process: ecs.Fatal(errMockup)}
+	sm := ecs.NewSystemManager()
+	sm.Schedule(ecs.AdaptErrorSystem(s1), ecs.Label("s1"), ecs.Reads(1))
+	sm.Schedule(ecs.AdaptErrorSystem(s2), ecs.Label("s2"), ecs.Reads(1))
+	engine := ecs.NewDefaultEngine(ecs.NewEntityManager(), sm, ecs.WithParallelSystems())
+	engine.Setup()
+	engine.Tick()
+	engine.Tick()
+	if s1.processed != 1 || s2.processed != 1 {
+		t.Errorf("Engine should stop after the stage, but got %d and %d calls", s1.processed, s2.processed)
+	}
+	errs := engine.Err().(interface{ Unwrap() []error }).Unwrap()
+	if len(errs) != 2 || errs[0].(*ecs.SystemError).System != s1 || errs[1].(*ecs.SystemError).System != s2 {
+		t.Errorf("Err should contain the errors in the order of the systems, but got %v", errs)
+	}
+}
+
+func TestAdaptErrorSystem_Process_Should_Stop_Like_Engine(t *testing.T) {
+	for name, tc := range map[string]struct {
+		sys   *mockupErrorSystem
+		state int
+	}{
+		"error":    {&mockupErrorSystem{process: errMockup}, ecs.StateEngineContinue},
+		"stop":     {&mockupErrorSystem{process: ecs.ErrEngineStop}, ecs.StateEngineStop},
+		"fatal":    {&mockupErrorSystem{process: ecs.Fatal(errMockup)}, ecs.StateEngineStop},
+		"setup":    {&mockupErrorSystem{setup: errMockup}, ecs.StateEngineStop},
+		"teardown": {&mockupErrorSystem{teardown: errMockup}, ecs.StateEngineContinue},
+	} {
+		t.Run(name, func(t *testing.T) {
+			system := ecs.AdaptErrorSystem(tc.sys)
+			system.Setup()
+			if state := system.Process(ecs.NewEntityManager()); state != tc.state {
+				t.Errorf("State should be %d, but got %d", tc.state, state)
+			}
+			system.Teardown()
+			if tc.sys.teardowns != 1 {
+				t.Error("Teardown should be called without panicking")
+			}
+		})
+	}
+}
+
+/*
+       _   _ _
+ _   _| |_(_) |___
+| | | | __| | / __|
+| |_| | |_| | \__ \
+ \__,_|\__|_|_|___/
+*/
+
+// mockupErrorSystem returns the configured errors.
+type mockupErrorSystem struct {
+	setup     error
+	process   error
+	teardown  error
+	processed int
+	teardowns int
+}
+
+func (s *mockupErrorSystem) Setup() error {
+	return s.setup
+}
+
+func (s *mockupErrorSystem) Process(ctx *ecs.Context) error {
+	s.processed++
+	return s.process
+}
+
+func (s *mockupErrorSystem) Teardown() error {
+	s.teardowns++
+	return s.teardown
+}
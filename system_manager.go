@@ -4,6 +4,18 @@ package ecs
 type SystemManager interface {
 	// Add systems to the this SystemManager.
 	Add(systems ...System)
-	// Systems returns internally stored systems.
+	// AddContext adds systems, which receive the Context of the current frame.
+	AddContext(systems ...ContextSystem)
+	// Schedule adds a system with its labels and its order relative to other labels.
+	Schedule(system System, opts ...SystemOption)
+	// Order returns the names of the systems in the order they are processed for debugging.
+	// It returns an ErrSystemCycle if the constraints of the systems contain a cycle.
+	Order() (names []string, err error)
+	// Systems returns internally stored systems in the order they are processed.
+	// It returns no system if Order returns an error.
 	Systems() []System
+	// Stages returns the systems in the order they are processed grouped into stages,
+	// which systems can be processed in parallel.
+	// It returns no stage if Order returns an error.
+	Stages() [][]System
 }
@@ -2,22 +2,97 @@ package ecs
 
 // defaultSystemManager
 type defaultSystemManager struct {
-	systems []System
+	scheduled []*scheduledSystem
+	systems   []System
+	stages    [][]System
+	dirty     bool
 }
 
 // Add systems to the defaultSystemManager.
 func (m *defaultSystemManager) Add(systems ...System) {
-	m.systems = append(m.systems, systems...)
+	for _, system := range systems {
+		m.Schedule(system)
+	}
+}
+
+// AddContext adds systems, which receive the Context of the current frame, to the defaultSystemManager.
+func (m *defaultSystemManager) AddContext(systems ...ContextSystem) {
+	for _, system := range systems {
+		m.Schedule(AdaptContextSystem(system))
+	}
+}
+
+// Schedule adds a system with its labels and its order relative to other labels.
+func (m *defaultSystemManager) Schedule(system System, opts ...SystemOption) {
+	s := &scheduledSystem{system: system}
+	for _, opt := range opts {
+		opt(s)
+	}
+	m.scheduled = append(m.scheduled, s)
+	m.dirty = true
+}
+
+// Order returns the names of the systems in the order they are processed.
+// A system is named by its first label or by its type.
+// It returns an ErrSystemCycle if the constraints of the systems contain a cycle.
+func (m *defaultSystemManager) Order() ([]string, error) {
+	sorted, err := schedule(m.scheduled)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(sorted))
+	for i, s := range sorted {
+		names[i] = s.name()
+	}
+	return names, nil
 }
 
-// Systems returns the system, which are internally stored.
+// Systems returns the systems in the order of their constraints.
+// It returns no system if the constraints contain a cycle, which is reported by Order.
 func (m *defaultSystemManager) Systems() []System {
+	if m.dirty {
+		m.resolve()
+	}
+
 	return m.systems
 }
 
+// Stages returns the systems in the order of their constraints grouped into stages.
+// The systems of a stage declared their access by Reads and Writes, which does not conflict,
+// so they can be processed in parallel.
+// It returns no stage if the constraints contain a cycle, which is reported by Order.
+func (m *defaultSystemManager) Stages() [][]System {
+	if m.dirty {
+		m.resolve()
+	}
+
+	return m.stages
+}
+
+// resolve sorts the systems and caches the result until the next system is added.
+func (m *defaultSystemManager) resolve() {
+	m.dirty = false
+	sorted, _ := schedule(m.scheduled)
+	m.systems = make([]System, 0, len(sorted))
+	for _, s := range sorted {
+		m.systems = append(m.systems, s.system)
+	}
+
+	m.stages = make([][]System, 0)
+	for _, stage := range stages(sorted) {
+		systems := make([]System, len(stage))
+		for i, s := range stage {
+			systems[i] = s.system
+		}
+		m.stages = append(m.stages, systems)
+	}
+}
+
 // NewSystemManager creates a new defaultSystemManager and returns its address.
 func NewSystemManager() SystemManager {
 	return &defaultSystemManager{
-		systems: []System{},
+		scheduled: []*scheduledSystem{},
+		systems:   []System{},
 	}
 }
@@ -1,6 +1,9 @@
 package ecs_test
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/bolom009/ecs"
@@ -32,6 +35,116 @@ func TestSystemManager_Systems_Should_Have_Two_System_After_Adding_Two_System(t
 	}
 }
 
+func TestSystemManager_Systems_Should_Follow_Before_And_After(t *testing.T) {
+	m := ecs.NewSystemManager()
+	render := &mockupDedicatedSystem{}
+	physics := &mockupDedicatedSystem{}
+	input := &mockupDedicatedSystem{}
+	m.Schedule(render, ecs.Label("render"), ecs.After("physics"))
+	m.Schedule(physics, ecs.Label("physics"))
+	m.Schedule(input, ecs.Label("input"), ecs.Before("physics", "unknown"))
+	systems := m.Systems()
+	if len(systems) != 3 || systems[0] != input || systems[1] != physics || systems[2] != render {
+		t.Error("Systems should be sorted by their constraints")
+	}
+	order, err := m.Order()
+	if err != nil {
+		t.Fatalf("Order should not return an error, but got %v", err)
+	}
+	if strings.Join(order, ",") != "input,physics,render" {
+		t.Errorf("Order should be input,physics,render, but got %v", order)
+	}
+}
+
+func TestSystemManager_Systems_Should_Keep_Registration_Order_Without_Constraints(t *testing.T) {
+	m := ecs.NewSystemManager()
+	s1 := &mockupDedicatedSystem{}
+	s2 := &mockupDedicatedSystem{}
+	s3 := &mockupDedicatedSystem{}
+	m.Add(s1, s2)
+	m.Schedule(s3, ecs.Before("first"))
+	m.Schedule(&mockupDedicatedSystem{}, ecs.Label("first"))
+	systems := m.Systems()
+	if systems[0] != s1 || systems[1] != s2 || systems[2] != s3 {
+		t.Error("Systems without constraints should keep their registration order")
+	}
+}
+
+func TestSystemManager_Order_Should_Return_Cycle(t *testing.T) {
+	m := ecs.NewSystemManager()
+	m.Schedule(&mockupDedicatedSystem{}, ecs.Label("a"), ecs.After("c"))
+	m.Schedule(&mockupDedicatedSystem{}, ecs.Label("b"), ecs.After("a"))
+	m.Schedule(&mockupDedicatedSystem{}, ecs.Label("c"), ecs.After("b"))
+	m.Schedule(&mockupDedicatedSystem{}, ecs.Label("d"), ecs.After("c"))
+	_, err := m.Order()
+	if !errors.Is(err, ecs.ErrSystemCycle) {
+		t.Fatalf("Order should return ErrSystemCycle, but got %v", err)
+	}
+	if !strings.Contains(err.Error(), "a -> b -> c -> a") {
+		t.Errorf("Error should contain the cycle, but got %v", err)
+	}
+	if len(m.Systems()) != 0 || len(m.Stages()) != 0 {
+		t.Error("Systems should be empty if the constraints contain a cycle")
+	}
+	engine := ecs.NewDefaultEngine(ecs.NewEntityManager(), m)
+	engine.Setup()
+	if err := engine.Err(); !errors.Is(err, ecs.ErrSystemCycle) || !ecs.IsFatal(err) {
+		t.Errorf("Setup should record the cycle as fatal error, but got %v", err)
+	}
+	if err := engine.RunContext(context.Background()); !errors.Is(err, ecs.ErrSystemCycle) {
+		t.Errorf("RunContext should return the cycle, but got %v", err)
+	}
+}
+
+func TestSystemManager_Order_Should_Name_Systems_By_Type(t *testing.T) {
+	m := ecs.NewSystemManager()
+	m.Add(&mockupDedicatedSystem{})
+	m.AddContext(&mockupContextSystem{})
+	order, _ := m.Order()
+	if strings.Join(order, ",") != "*ecs_test.mockupDedicatedSystem,*ecs_test.mockupContextSystem" {
+		t.Errorf("Systems without label should be named by their type, but got %v", order)
+	}
+}
+
+func TestSystemManager_Stages_Should_Group_Non_Conflicting_Systems(t *testing.T) {
+	m := ecs.NewSystemManager()
+	move := &mockupDedicatedSystem{}
+	animate := &mockupDedicatedSystem{}
+	collide := &mockupDedicatedSystem{}
+	render := &mockupDedicatedSystem{}
+	log := &mockupDedicatedSystem{}
+	m.Schedule(move, ecs.Reads(2), ecs.Writes(1))
+	m.Schedule(animate, ecs.Reads(2), ecs.Writes(4))
+	// collide reads the position written by move.
+	m.Schedule(collide, ecs.Reads(1))
+	m.Schedule(render, ecs.Label("render"), ecs.Reads(1|4))
+	// log runs after render, although it does not conflict.
+	m.Schedule(log, ecs.After("render"), ecs.Reads(1))
+	stages := m.Stages()
+	expected := [][]ecs.System{{move, animate}, {collide, render}, {log}}
+	if len(stages) != len(expected) {
+		t.Fatalf("SystemManager should have %d stages, but got %d", len(expected), len(stages))
+	}
+	for i := range expected {
+		if len(stages[i]) != len(expected[i]) {
+			t.Fatalf("Stage %d should have %d systems, but got %d", i, len(expected[i]), len(stages[i]))
+		}
+		for j := range expected[i] {
+			if stages[i][j] != expected[i][j] {
+				t.Errorf("Stage %d should contain the expected system at %d", i, j)
+			}
+		}
+	}
+}
+
+func TestSystemManager_Stages_Should_Separate_Systems_Without_Access(t *testing.T) {
+	m := ecs.NewSystemManager()
+	m.Add(&mockupDedicatedSystem{}, &mockupDedicatedSystem{})
+	if len(m.Stages()) != 2 {
+		t.Errorf("Systems without declared access should have their own stage, but got %d", len(m.Stages()))
+	}
+}
+
 /*
        _   _ _
  _   _| |_(_) |___
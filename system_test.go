@@ -0,0 +1,86 @@
+package ecs_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bolom009/ecs"
+)
+
+func TestDefaultEngine_Tick_Should_Pass_Context_To_ContextSystem(t *testing.T) {
+	em := ecs.NewEntityManager()
+	cb := ecs.NewCommandBuffer()
+	bus := ecs.NewEventBus()
+	sys := &mockupContextSystem{}
+	legacy := &mockupSystem{}
+	sm := ecs.NewSystemManager()
+	sm.AddContext(sys)
+	sm.Add(legacy)
+	clock := &mockupClock{now: time.Unix(0, 0)}
+	engine := ecs.NewDefaultEngine(em, sm, ecs.WithCommandBuffer(cb), ecs.WithEventBus(bus), ecs.WithClock(clock))
+	engine.Tick()
+	clock.now = clock.now.Add(time.Millisecond)
+	engine.Tick()
+	if len(sys.ticks) != 2 || sys.ticks[0] != 1 || sys.ticks[1] != 2 {
+		t.Errorf("Context should contain the world ticks 1 and 2, but got %v", sys.ticks)
+	}
+	if sys.world != em || sys.commands != cb || sys.events != bus || sys.resources != em.Resources() {
+		t.Error("Context should contain the World, Commands, Events and Resources of the Engine")
+	}
+	if sys.deltaTime[0] != 0 || sys.deltaTime[1] != int64(time.Millisecond) {
+		t.Errorf("DeltaTime should be the time elapsed since the previous tick, but got %v", sys.deltaTime)
+	}
+	if len(em.Entities()) != 2 {
+		t.Errorf("Spawned entities should be flushed, but got %d", len(em.Entities()))
+	}
+	if legacy.Counter != 2 {
+		t.Errorf("Legacy System should still be processed, but got %d", legacy.Counter)
+	}
+}
+
+func TestAdaptContextSystem_Process_Should_Call_ProcessContext(t *testing.T) {
+	em := ecs.NewEntityManager()
+	sys := &mockupContextSystem{}
+	ecs.AdaptContextSystem(sys).Process(em)
+	if sys.world != em || len(sys.ticks) != 1 || sys.ctxErr != nil {
+		t.Error("Process should call ProcessContext with the EntityManager")
+	}
+	if sys.events == nil || sys.resources != em.Resources() {
+		t.Error("Process should pass the Events and the Resources")
+	}
+	if len(em.Entities()) != 1 {
+		t.Errorf("Process should apply the Commands, but got %d entities", len(em.Entities()))
+	}
+}
+
+/*
+       _   _ _
+ _   _| |_(_) |___
+| | | | __| | / __|
+| |_| | |_| | \__ \
+ \__,_|\__|_|_|___/
+*/
+
+// mockupContextSystem records the Context and spawns an entity using its Commands.
+type mockupContextSystem struct {
+	ticks     []uint64
+	deltaTime []int64
+	world     ecs.EntityManager
+	commands  *ecs.CommandBuffer
+	events    *ecs.EventBus
+	resources *ecs.Resources
+	ctxErr    error
+}
+
+func (s *mockupContextSystem) ProcessContext(ctx *ecs.Context) (state int) {
+	s.ticks = append(s.ticks, ctx.Tick)
+	s.deltaTime = append(s.deltaTime, int64(ctx.DeltaTime))
+	s.world, s.commands, s.events, s.resources = ctx.World, ctx.Commands, ctx.Events, ctx.Resources
+	s.ctxErr = ctx.Err()
+	if ctx.Commands != nil {
+		ctx.Commands.Spawn()
+	}
+	return ecs.StateEngineContinue
+}
+func (s *mockupContextSystem) Setup()    {}
+func (s *mockupContextSystem) Teardown() {}